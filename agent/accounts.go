@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strconv"
+
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"github.com/ubtr/ubt/go/api/proto"
+	"google.golang.org/grpc/metadata"
+)
+
+// gRPC metadata key with non-hardened address index to derive from account level extended public key
+const MetadataDerivationIndex = "ubt-derivation-index"
+
+// gRPC response header with BIP44 path of the derived address
+const MetadataDerivationPath = "ubt-derivation-path"
+
+// get derivation index from incoming gRPC metadata, error if not provided
+func DerivationIndexFromContext(ctx context.Context) (uint32, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	vals := md.Get(MetadataDerivationIndex)
+	if len(vals) == 0 || vals[0] == "" {
+		return 0, rpcerrors.ArgError(MetadataDerivationIndex, errors.New("derivation index is required"))
+	}
+	index, err := strconv.ParseUint(vals[0], 10, 31)
+	if err != nil {
+		return 0, rpcerrors.ArgError(MetadataDerivationIndex, err)
+	}
+	return uint32(index), nil
+}
+
+type AccountKind int
+
+const (
+	AccountEOA       AccountKind = iota // externally owned account without code
+	AccountContract                     // smart contract
+	AccountDelegated                    // externally owned account delegating its code to a contract (EIP-7702)
+)
+
+func (k AccountKind) String() string {
+	switch k {
+	case AccountContract:
+		return "contract"
+	case AccountDelegated:
+		return "delegated"
+	default:
+		return "eoa"
+	}
+}
+
+// on-chain state of the account
+type AccountInfo struct {
+	Address     string
+	Kind        AccountKind
+	Nonce       uint64
+	CodeHash    []byte
+	DelegatedTo string // delegation target of AccountDelegated
+	Activated   *bool  // nil if chain has no account activation
+}
+
+// account type flags of proto.Account: delegated accounts are both standard and contract
+func (i *AccountInfo) ProtoType() uint32 {
+	switch i.Kind {
+	case AccountContract:
+		return uint32(proto.Account_CONTRACT)
+	case AccountDelegated:
+		return uint32(proto.Account_STANDARD) | uint32(proto.Account_CONTRACT)
+	default:
+		return uint32(proto.Account_STANDARD)
+	}
+}
+
+// gRPC response headers with account details not covered by proto.Account
+const (
+	MetadataAccountKind        = "ubt-account-kind"
+	MetadataAccountNonce       = "ubt-account-nonce"
+	MetadataAccountCodeHash    = "ubt-account-code-hash"
+	MetadataAccountDelegatedTo = "ubt-account-delegated-to"
+	MetadataAccountActivated   = "ubt-account-activated"
+)
+
+// account details as gRPC response header metadata
+func (i *AccountInfo) Metadata() metadata.MD {
+	md := metadata.Pairs(
+		MetadataAccountKind, i.Kind.String(),
+		MetadataAccountNonce, strconv.FormatUint(i.Nonce, 10),
+	)
+	if len(i.CodeHash) > 0 {
+		md.Set(MetadataAccountCodeHash, "0x"+hex.EncodeToString(i.CodeHash))
+	}
+	if i.DelegatedTo != "" {
+		md.Set(MetadataAccountDelegatedTo, i.DelegatedTo)
+	}
+	if i.Activated != nil {
+		md.Set(MetadataAccountActivated, strconv.FormatBool(*i.Activated))
+	}
+	return md
+}
+
+type AccountInfoProvider interface {
+	GetAccountInfo(ctx context.Context, address string) (*AccountInfo, error)
+}
@@ -11,6 +11,7 @@ type UbtAgent interface {
 	services.UbtBlockServiceServer
 	services.UbtConstructServiceServer
 	services.UbtCurrencyServiceServer
+	services.UbtBalanceServiceServer
 	String() string
 }
 
@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"google.golang.org/grpc/metadata"
+)
+
+// gRPC metadata key with hex id (optionally 0x prefixed) of the last block seen by the client, it must be the parent of the first requested block
+const MetadataLastBlockId = "ubt-last-block-id"
+
+// get last seen block id from incoming gRPC metadata, nil if not provided
+func LastBlockIdFromContext(ctx context.Context) ([]byte, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	vals := md.Get(MetadataLastBlockId)
+	if len(vals) == 0 || vals[0] == "" {
+		return nil, nil
+	}
+	id, err := hex.DecodeString(strings.TrimPrefix(vals[0], "0x"))
+	if err != nil {
+		return nil, rpcerrors.ArgError(MetadataLastBlockId, err)
+	}
+	return id, nil
+}
+
+// gRPC metadata key to keep ListBlocks stream open and push new blocks as they arrive
+const MetadataFollow = "ubt-follow"
+
+// get follow mode flag from incoming gRPC metadata
+func FollowFromContext(ctx context.Context) (bool, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false, nil
+	}
+	vals := md.Get(MetadataFollow)
+	if len(vals) == 0 || vals[0] == "" {
+		return false, nil
+	}
+	follow, err := strconv.ParseBool(vals[0])
+	if err != nil {
+		return false, rpcerrors.ArgError(MetadataFollow, err)
+	}
+	return follow, nil
+}
+
+// gRPC metadata key with decimal block number to query state at, latest if not set
+const MetadataBlockNumber = "ubt-block-number"
+
+// get requested block number from incoming gRPC metadata, nil for latest
+func BlockNumberFromContext(ctx context.Context) (*big.Int, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	vals := md.Get(MetadataBlockNumber)
+	if len(vals) == 0 || vals[0] == "" {
+		return nil, nil
+	}
+	number, ok := new(big.Int).SetString(vals[0], 10)
+	if !ok || number.Sign() < 0 {
+		return nil, rpcerrors.ArgError(MetadataBlockNumber, fmt.Errorf("invalid block number '%s'", vals[0]))
+	}
+	return number, nil
+}
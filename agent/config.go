@@ -2,6 +2,7 @@ package agent
 
 import (
 	"os"
+	"time"
 
 	"github.com/ubtr/ubt-go/commons"
 
@@ -17,14 +18,38 @@ type UrlConfig struct {
 	Name     string `yaml:"name"`
 	Url      string `yaml:"url"`
 	LimitRps uint   `yaml:"limitRps"`
+	Trace    bool   `yaml:"trace"` // upstream supports debug_trace* calls
+	WsUrl    string `yaml:"wsUrl"` // websocket endpoint of the upstream to subscribe for new heads
 }
 
 type ChainConfig struct {
-	Testnet      bool        `yaml:"testnet"`
-	ChainType    string      `yaml:"-"`
-	ChainNetwork string      `yaml:"-"`
-	RpcUrls      []UrlConfig `yaml:"rpcUrls"`
-	HttpUrls     []UrlConfig `yaml:"httpUrls"`
+	Testnet            bool               `yaml:"testnet"`
+	ChainType          string             `yaml:"-"`
+	ChainNetwork       string             `yaml:"-"`
+	RpcUrls            []UrlConfig        `yaml:"rpcUrls"`
+	HttpUrls           []UrlConfig        `yaml:"httpUrls"`
+	LegacyTx           bool               `yaml:"legacyTx"`           // build pre EIP-1559 transactions, for networks without dynamic fees
+	FeeSpeed           string             `yaml:"feeSpeed"`           // default fee speed tier: slow, normal or fast
+	NonceDb            string             `yaml:"nonceDb"`            // postgres dsn to share nonce reservations between replicas; in-memory if empty
+	InternalTransfers  bool               `yaml:"internalTransfers"`  // report native transfers made by contracts, requires upstreams with trace enabled
+	HeadPollInterval   time.Duration      `yaml:"headPollInterval"`   // how often to fetch safe and finalized heads
+	SafeDepth          uint64             `yaml:"safeDepth"`          // confirmations to consider block safe if node lacks the `safe` tag
+	FinalityDepth      uint64             `yaml:"finalityDepth"`      // confirmations to consider block finalized if node lacks the `finalized` tag
+	FeeRecipientIncome bool               `yaml:"feeRecipientIncome"` // report block producer fee income as block-level transfers
+	Multicall3         string             `yaml:"multicall3"`         // Multicall3 contract address used for batched balance queries, canonical deployment if empty
+	CurrencyDb         string             `yaml:"currencyDb"`         // postgres:// dsn or sqlite file of persistent currency registry; disabled if empty
+	TokenLists         []string           `yaml:"tokenLists"`         // token list json files imported into currency registry on start
+	Currencies         []CurrencyOverride `yaml:"currencies"`         // operator overrides of currency metadata
+	BroadcastAll       bool               `yaml:"broadcastAll"`       // send transactions to every connected upstream instead of one
+}
+
+// currency metadata set by operator, takes precedence over token lists and on-chain data
+type CurrencyOverride struct {
+	Id       string `yaml:"id"`
+	Symbol   string `yaml:"symbol"`
+	Name     string `yaml:"name"`
+	Decimals uint32 `yaml:"decimals"`
+	IconUrl  string `yaml:"iconUrl"`
 }
 
 type Config struct {
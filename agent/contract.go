@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ubtr/ubt/go/api/proto/services"
+)
+
+// generic contract call; calldata is either given abi encoded or built from method signature and json arguments
+type ContractCallRequest struct {
+	From     string
+	To       string          // contract address
+	Data     []byte          // abi encoded calldata; or
+	Method   string          // method signature, e.g. "transfer(address,uint256)"
+	Args     json.RawMessage // json array of method arguments
+	Value    *big.Int        // optional native amount sent with the call
+	GasLimit uint64          // optional override of estimated gas; fee limit in suns for trx
+}
+
+// contract deployment; constructor arguments are abi encoded and appended to bytecode
+type ContractDeployRequest struct {
+	From            string
+	Bytecode        []byte
+	ConstructorArgs []byte
+	Value           *big.Int // optional native amount sent to constructor
+	GasLimit        uint64   // optional override of estimated gas; fee limit in suns for trx
+	Name            string   // contract name, used by trx only
+	// trx only: max energy of the deployer spent per contract call, default is used if zero
+	OriginEnergyLimit uint64
+}
+
+// agent able to build intents for arbitrary contract interactions; intents are signed and sent as usual.
+// Not reachable over gRPC: construct service only creates transfers, so callers use agents directly
+type ContractIntentProvider interface {
+	CreateContractCall(ctx context.Context, req *ContractCallRequest) (*services.TransactionIntent, error)
+	CreateContractDeploy(ctx context.Context, req *ContractDeployRequest) (*services.TransactionIntent, error)
+}
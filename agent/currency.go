@@ -0,0 +1,20 @@
+package agent
+
+import (
+	"context"
+	"math/big"
+)
+
+// erc-20 like token metadata; name, symbol and total supply are optional in the standard and left empty if not provided
+type TokenMetadata struct {
+	Name        string
+	Symbol      string
+	Decimals    uint8
+	TotalSupply *big.Int
+}
+
+// agent able to read current token metadata from chain, bypassing currency cache and registry;
+// Go API as proto currency has no total supply
+type TokenMetadataProvider interface {
+	GetTokenMetadata(ctx context.Context, currencyId string) (*TokenMetadata, error)
+}
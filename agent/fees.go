@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"google.golang.org/grpc/metadata"
+)
+
+// fee speed tier used to price transaction intents
+type FeeSpeed int
+
+const (
+	FeeSpeedNormal FeeSpeed = iota
+	FeeSpeedSlow
+	FeeSpeedFast
+)
+
+// gRPC metadata keys to pass fee options with construct requests
+const MetadataFeeSpeed = "ubt-fee-speed"
+const MetadataMaxFee = "ubt-max-fee"
+
+func (s FeeSpeed) String() string {
+	switch s {
+	case FeeSpeedSlow:
+		return "slow"
+	case FeeSpeedFast:
+		return "fast"
+	default:
+		return "normal"
+	}
+}
+
+func FeeSpeedFromString(speed string) (FeeSpeed, error) {
+	switch strings.ToLower(speed) {
+	case "", "normal":
+		return FeeSpeedNormal, nil
+	case "slow":
+		return FeeSpeedSlow, nil
+	case "fast":
+		return FeeSpeedFast, nil
+	default:
+		return FeeSpeedNormal, fmt.Errorf("unknown fee speed '%s'", speed)
+	}
+}
+
+type FeeOptions struct {
+	Speed  FeeSpeed
+	MaxFee *big.Int // explicit max fee, per gas for eth-like chains; overrides Speed if set
+}
+
+// get fee options from incoming gRPC metadata, using chain defaults for missing values
+func FeeOptionsFromContext(ctx context.Context, config *ChainConfig) (FeeOptions, error) {
+	speedStr := config.FeeSpeed
+	maxFeeStr := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vals := md.Get(MetadataFeeSpeed); len(vals) > 0 {
+			speedStr = vals[0]
+		}
+		if vals := md.Get(MetadataMaxFee); len(vals) > 0 {
+			maxFeeStr = vals[0]
+		}
+	}
+
+	var opts FeeOptions
+	speed, err := FeeSpeedFromString(speedStr)
+	if err != nil {
+		return opts, rpcerrors.ArgError(MetadataFeeSpeed, err)
+	}
+	opts.Speed = speed
+
+	if maxFeeStr != "" {
+		maxFee, ok := new(big.Int).SetString(maxFeeStr, 0)
+		if !ok || maxFee.Sign() <= 0 {
+			return opts, rpcerrors.ArgError(MetadataMaxFee, fmt.Errorf("invalid value '%s'", maxFeeStr))
+		}
+		opts.MaxFee = maxFee
+	}
+	return opts, nil
+}
@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"github.com/ubtr/ubt/go/api/proto/services"
+	"google.golang.org/grpc/metadata"
+)
+
+type SimulationRequest struct {
+	Intent *services.TransactionIntent
+	// sender of the intent; recovered from signature if set, not needed if raw data carries the sender (trx)
+	From       string
+	Signatures [][]byte
+}
+
+// outcome of executing the intent against pending state
+type SimulationResult struct {
+	Success      bool
+	RevertReason string // decoded revert reason or execution error of failed simulation
+	GasUsed      uint64 // gas estimate; energy for trx
+	ReturnData   []byte
+}
+
+// intent simulation for Go callers; over gRPC only dry-run Send (MetadataDryRun) is available
+type Simulator interface {
+	SimulateIntent(ctx context.Context, req *SimulationRequest) (*SimulationResult, error)
+}
+
+// gRPC metadata key to validate signed transaction on Send without broadcasting it
+const MetadataDryRun = "ubt-dry-run"
+
+// get dry run flag from incoming gRPC metadata
+func DryRunFromContext(ctx context.Context) (bool, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return false, nil
+	}
+	vals := md.Get(MetadataDryRun)
+	if len(vals) == 0 || vals[0] == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(vals[0])
+	if err != nil {
+		return false, rpcerrors.ArgError(MetadataDryRun, err)
+	}
+	return dryRun, nil
+}
@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"context"
+	"math/big"
+)
+
+type TxStatus int
+
+const (
+	TxStatusUnknown  TxStatus = iota
+	TxStatusPending           // known to the node but not included yet
+	TxStatusIncluded          // included and succeeded
+	TxStatusFailed            // included but reverted
+	TxStatusDropped           // not known to the node anymore
+	TxStatusReplaced          // other transaction with the same nonce was included
+)
+
+func (s TxStatus) String() string {
+	switch s {
+	case TxStatusPending:
+		return "pending"
+	case TxStatusIncluded:
+		return "included"
+	case TxStatusFailed:
+		return "failed"
+	case TxStatusDropped:
+		return "dropped"
+	case TxStatusReplaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
+}
+
+type TransactionStatusRequest struct {
+	Id   []byte // transaction id as returned by Send
+	From string // optional sender, with RawData allows to detect replacement
+	// optional raw intent data, see services.TransactionIntent
+	RawData []byte
+}
+
+type TransactionStatus struct {
+	Id            []byte
+	Status        TxStatus
+	BlockId       []byte
+	BlockNumber   uint64
+	Confirmations uint64
+	GasUsed       uint64
+	EffectiveFee  *big.Int // actual fee in native currency
+	RevertReason  string   // decoded revert reason of failed transaction, if available
+}
+
+// agent able to track sent transactions; Go API for embedding applications,
+// ubt proto has no status call so it is not served over gRPC
+type TxStatusProvider interface {
+	GetTransactionStatus(ctx context.Context, req *TransactionStatusRequest) (*TransactionStatus, error)
+}
@@ -8,8 +8,33 @@ import (
 	"github.com/ubtr/ubt-go/blockchain/bnb"
 )
 
+// https://github.com/bnb-chain/BEPs/blob/master/BEPs/BEP126.md
+// with fast finality a block is justified by votes of its child and finalized once its child is justified,
+// normally two blocks behind the head; without votes finality is probabilistic after 2/3 of 21 validators sealed blocks
+const (
+	SafeDepth     = 2
+	FinalityDepth = 15
+)
+
+var BnbExtensions = ethagent.Extensions{
+	// bsc has zero base fee so whole gas fee is paid to the validator coinbase,
+	// it is moved to the validator set contract by a system transaction reported as usual transfer
+	BlockRewards: ethagent.DefaultBlockRewards,
+	NewHeadsFetcher: func(srv *ethagent.EthServer) ethagent.HeadsFetcher {
+		safeDepth, finalityDepth := uint64(SafeDepth), uint64(FinalityDepth)
+		if srv.Config.SafeDepth != 0 {
+			safeDepth = srv.Config.SafeDepth
+		}
+		if srv.Config.FinalityDepth != 0 {
+			finalityDepth = srv.Config.FinalityDepth
+		}
+		// bsc nodes serve `safe` and `finalized` tags from fast finality votes, depth is used for nodes without them
+		return ethagent.NewTagHeadsFetcher(srv.C, safeDepth, finalityDepth, srv.Log).Fetch
+	},
+}
+
 func init() {
 	agent.AgentFactories[bnb.CODE_STR] = func(ctx context.Context, config *agent.ChainConfig) agent.UbtAgent {
-		return ethagent.InitServer(ctx, config)
+		return ethagent.InitServerWithExtensions(ctx, config, BnbExtensions)
 	}
 }
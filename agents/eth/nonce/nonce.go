@@ -0,0 +1,159 @@
+/*
+  Nonce reservation for transaction intents created before previous ones are broadcast
+*/
+
+package nonce
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// reservations are dropped and nonce resynced from chain if a nonce reserved that long ago is still not used on chain
+const DefaultTtl = 10 * time.Minute
+
+// nonce handed out to an intent
+type Reservation struct {
+	Nonce uint64
+	At    time.Time
+}
+
+// nonce state of a single address
+type State struct {
+	Next      uint64        // next nonce to hand out if there are no released ones
+	Released  []uint64      // nonces of abandoned intents, reused before Next
+	Reserved  []Reservation // handed out nonces not yet seen on chain
+	UpdatedAt time.Time     // last reservation or release time
+}
+
+// reserve nonce; chainNonce is the pending nonce reported by the chain
+func (s *State) reserve(chainNonce uint64, now time.Time, ttl time.Duration) uint64 {
+	// everything below chain nonce is already used
+	s.Reserved = slices.DeleteFunc(s.Reserved, func(r Reservation) bool { return r.Nonce < chainNonce })
+	if s.UpdatedAt.IsZero() || s.hasStale(now, ttl) {
+		// intent of stale reservation was never sent, its gap blocks all later transactions
+		s.resync(chainNonce)
+	}
+	s.Released = slices.DeleteFunc(s.Released, func(n uint64) bool { return n < chainNonce })
+	if s.Next < chainNonce {
+		s.Next = chainNonce
+	}
+	s.UpdatedAt = now
+
+	var nonce uint64
+	if len(s.Released) > 0 {
+		slices.Sort(s.Released)
+		nonce = s.Released[0]
+		s.Released = s.Released[1:]
+	} else {
+		nonce = s.Next
+		s.Next++
+	}
+	s.Reserved = append(s.Reserved, Reservation{Nonce: nonce, At: now})
+	return nonce
+}
+
+// any reservation older than ttl which chain nonce did not move past
+func (s *State) hasStale(now time.Time, ttl time.Duration) bool {
+	return slices.ContainsFunc(s.Reserved, func(r Reservation) bool { return now.Sub(r.At) > ttl })
+}
+
+// return nonce of abandoned intent so it is reused by the next reservation
+func (s *State) release(nonce uint64, now time.Time) {
+	if nonce >= s.Next || slices.Contains(s.Released, nonce) {
+		return
+	}
+	s.UpdatedAt = now
+	s.Reserved = slices.DeleteFunc(s.Reserved, func(r Reservation) bool { return r.Nonce == nonce })
+	if nonce == s.Next-1 {
+		s.Next--
+		// collapse released tail
+		for len(s.Released) > 0 && slices.Contains(s.Released, s.Next-1) {
+			s.Next--
+			s.Released = slices.DeleteFunc(s.Released, func(n uint64) bool { return n == s.Next })
+		}
+		return
+	}
+	s.Released = append(s.Released, nonce)
+}
+
+func (s *State) resync(chainNonce uint64) {
+	s.Next = chainNonce
+	s.Released = nil
+	s.Reserved = nil
+}
+
+// storage of per address nonce states; update must be atomic for the key
+type Store interface {
+	Update(ctx context.Context, key string, fn func(state *State) error) error
+}
+
+type Manager struct {
+	store  Store
+	prefix string // distinguishes chains sharing the same store
+	ttl    time.Duration
+}
+
+func NewManager(store Store, prefix string, ttl time.Duration) *Manager {
+	return &Manager{store: store, prefix: prefix, ttl: ttl}
+}
+
+func (m *Manager) key(address string) string {
+	return m.prefix + ":" + address
+}
+
+// hand out next nonce for address
+func (m *Manager) Reserve(ctx context.Context, address string, chainNonce uint64) (uint64, error) {
+	var nonce uint64
+	err := m.store.Update(ctx, m.key(address), func(state *State) error {
+		nonce = state.reserve(chainNonce, time.Now(), m.ttl)
+		return nil
+	})
+	return nonce, err
+}
+
+// release nonce of the intent that will never be sent
+func (m *Manager) Release(ctx context.Context, address string, nonce uint64) error {
+	return m.store.Update(ctx, m.key(address), func(state *State) error {
+		state.release(nonce, time.Now())
+		return nil
+	})
+}
+
+// drop all reservations and continue from the chain nonce
+func (m *Manager) Resync(ctx context.Context, address string, chainNonce uint64) error {
+	return m.store.Update(ctx, m.key(address), func(state *State) error {
+		state.resync(chainNonce)
+		state.UpdatedAt = time.Now()
+		return nil
+	})
+}
+
+// in-memory store, suitable for a single agent instance
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]*State
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]*State)}
+}
+
+func (s *MemoryStore) Update(ctx context.Context, key string, fn func(state *State) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[key]
+	if !ok {
+		state = &State{}
+	}
+	updated := *state
+	updated.Released = slices.Clone(state.Released)
+	updated.Reserved = slices.Clone(state.Reserved)
+	if err := fn(&updated); err != nil {
+		return err
+	}
+	s.states[key] = &updated
+	return nil
+}
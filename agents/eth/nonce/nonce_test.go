@@ -0,0 +1,111 @@
+package nonce
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func runTestReserveRelease(t *testing.T, store Store) {
+	ctx := context.TODO()
+	m := NewManager(store, "ETH:TEST", DefaultTtl)
+
+	for i := uint64(0); i < 3; i++ {
+		n, err := m.Reserve(ctx, "a", 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 5+i {
+			t.Errorf("expected nonce %d, got %d", 5+i, n)
+		}
+	}
+
+	// addresses are independent
+	n, _ := m.Reserve(ctx, "b", 1)
+	if n != 1 {
+		t.Errorf("expected nonce 1 for other address, got %d", n)
+	}
+
+	// gap is reused
+	if err := m.Release(ctx, "a", 6); err != nil {
+		t.Fatal(err)
+	}
+	n, _ = m.Reserve(ctx, "a", 5)
+	if n != 6 {
+		t.Errorf("expected released nonce 6, got %d", n)
+	}
+	n, _ = m.Reserve(ctx, "a", 5)
+	if n != 8 {
+		t.Errorf("expected nonce 8, got %d", n)
+	}
+
+	// releasing tail moves next back
+	m.Release(ctx, "a", 7)
+	m.Release(ctx, "a", 8)
+	n, _ = m.Reserve(ctx, "a", 5)
+	if n != 7 {
+		t.Errorf("expected nonce 7 after tail release, got %d", n)
+	}
+
+	// chain moved ahead
+	n, _ = m.Reserve(ctx, "a", 20)
+	if n != 20 {
+		t.Errorf("expected chain nonce 20, got %d", n)
+	}
+
+	if err := m.Resync(ctx, "a", 10); err != nil {
+		t.Fatal(err)
+	}
+	n, _ = m.Reserve(ctx, "a", 10)
+	if n != 10 {
+		t.Errorf("expected resynced nonce 10, got %d", n)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	runTestReserveRelease(t, NewMemoryStore())
+}
+
+func TestSqlStore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := NewSqlStore(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runTestReserveRelease(t, store)
+}
+
+func TestStaleReservationResync(t *testing.T) {
+	var s State
+	start := time.Now()
+	s.reserve(5, start, DefaultTtl) // never sent
+	if n := s.reserve(5, start.Add(time.Minute), DefaultTtl); n != 6 {
+		t.Fatalf("expected nonce 6, got %d", n)
+	}
+
+	// steady traffic keeps the state fresh, but chain is stuck at the gap
+	for i := time.Duration(2); i < 10; i++ {
+		if n := s.reserve(5, start.Add(i*time.Minute), DefaultTtl); n != 5+uint64(i) {
+			t.Fatalf("expected nonce %d, got %d", 5+i, n)
+		}
+	}
+	if n := s.reserve(5, start.Add(DefaultTtl+time.Second), DefaultTtl); n != 5 {
+		t.Errorf("expected resync to chain nonce 5, got %d", n)
+	}
+
+	// reservations used on chain are not stale
+	var used State
+	used.reserve(5, start, DefaultTtl)
+	if n := used.reserve(6, start.Add(2*DefaultTtl), DefaultTtl); n != 6 {
+		t.Errorf("expected nonce 6, got %d", n)
+	}
+	if len(used.Reserved) != 1 {
+		t.Errorf("expected one outstanding reservation, got %d", len(used.Reserved))
+	}
+}
@@ -0,0 +1,90 @@
+package nonce
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// row of the shared nonce state
+type NonceState struct {
+	Id       string `gorm:"primaryKey"`
+	Next     uint64
+	Released string // json encoded list of released nonces
+	Reserved string // json encoded list of outstanding reservations
+	LastUsed time.Time
+}
+
+// store shared by several agent replicas; rows are locked for the update duration
+type SqlStore struct {
+	db *gorm.DB
+}
+
+func NewSqlStore(db *gorm.DB) (*SqlStore, error) {
+	if err := db.AutoMigrate(&NonceState{}); err != nil {
+		return nil, err
+	}
+	return &SqlStore{db: db}, nil
+}
+
+func (s *SqlStore) Update(ctx context.Context, key string, fn func(state *State) error) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// make sure row exists so it can be locked
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&NonceState{Id: key, Released: "[]", Reserved: "[]"}).Error
+		if err != nil {
+			return err
+		}
+
+		var row NonceState
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&row, "id = ?", key)
+		if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+			row = NonceState{Id: key}
+		} else if res.Error != nil {
+			return res.Error
+		}
+
+		state := State{Next: row.Next, UpdatedAt: row.LastUsed}
+		if row.Released != "" {
+			if err := json.Unmarshal([]byte(row.Released), &state.Released); err != nil {
+				return err
+			}
+		}
+		if row.Reserved != "" {
+			if err := json.Unmarshal([]byte(row.Reserved), &state.Reserved); err != nil {
+				return err
+			}
+		}
+
+		if err := fn(&state); err != nil {
+			return err
+		}
+
+		released, err := json.Marshal(state.Released)
+		if err != nil {
+			return err
+		}
+		reserved, err := json.Marshal(state.Reserved)
+		if err != nil {
+			return err
+		}
+		row.Next = state.Next
+		row.Released = string(released)
+		row.Reserved = string(reserved)
+		row.LastUsed = state.UpdatedAt
+		return tx.Save(&row).Error
+	})
+}
+
+// open postgres backed store
+func OpenSqlStore(dsn string) (*SqlStore, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	return NewSqlStore(db)
+}
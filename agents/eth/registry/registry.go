@@ -0,0 +1,112 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/ubtr/ubt/go/api/proto"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// origin of currency metadata; a record is replaced only by one of the same or higher priority
+type Source int
+
+const (
+	SourceChain     Source = iota // fetched from the token contract
+	SourceTokenList               // imported from token list
+	SourceOverride                // set by operator
+)
+
+// row of the currency registry
+type CurrencyRecord struct {
+	Chain      string `gorm:"primaryKey"` // chain id like ETH:MAINNET
+	CurrencyId string `gorm:"primaryKey"`
+	Symbol     string
+	Name       string
+	Decimals   uint32
+	IconUrl    string
+	Source     Source
+	UpdatedAt  time.Time
+}
+
+func (r *CurrencyRecord) ToProto() *proto.Currency {
+	ret := &proto.Currency{Id: r.CurrencyId, Symbol: r.Symbol, Decimals: r.Decimals}
+	if r.Name != "" || r.IconUrl != "" {
+		ret.Metadata = &proto.CurrencyMetadata{Name: r.Name, IconUrl: r.IconUrl}
+	}
+	return ret
+}
+
+func RecordFromProto(chain string, currency *proto.Currency, source Source) *CurrencyRecord {
+	ret := &CurrencyRecord{Chain: chain, CurrencyId: currency.Id, Symbol: currency.Symbol, Decimals: currency.Decimals, Source: source}
+	if currency.Metadata != nil {
+		ret.Name = currency.Metadata.Name
+		ret.IconUrl = currency.Metadata.IconUrl
+	}
+	return ret
+}
+
+// persistent currency metadata shared by agent restarts and replicas
+type Registry struct {
+	db *gorm.DB
+}
+
+func NewRegistry(db *gorm.DB) (*Registry, error) {
+	if err := db.AutoMigrate(&CurrencyRecord{}); err != nil {
+		return nil, err
+	}
+	return &Registry{db: db}, nil
+}
+
+// open registry in postgres for postgres:// dsn, sqlite database file otherwise
+func OpenRegistry(dsn string) (*Registry, error) {
+	var dialector gorm.Dialector
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		dialector = postgres.Open(dsn)
+	} else {
+		dialector = sqlite.Open(dsn)
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	return NewRegistry(db)
+}
+
+// get currency record, nil if not registered
+func (r *Registry) Get(ctx context.Context, chain string, currencyId string) (*CurrencyRecord, error) {
+	var row CurrencyRecord
+	res := r.db.WithContext(ctx).First(&row, "chain = ? AND currency_id = ?", chain, currencyId)
+	if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return &row, nil
+}
+
+// insert or replace records unless existing ones come from a source of higher priority
+func (r *Registry) Put(ctx context.Context, records ...*CurrencyRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain"}, {Name: "currency_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"symbol", "name", "decimals", "icon_url", "source", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "currency_records.source <= excluded.source"},
+		}},
+	}).Create(records).Error
+}
+
+// remove operator override so metadata is refreshed from other sources
+func (r *Registry) DeleteOverride(ctx context.Context, chain string, currencyId string) error {
+	return r.db.WithContext(ctx).Where("chain = ? AND currency_id = ? AND source = ?", chain, currencyId, SourceOverride).
+		Delete(&CurrencyRecord{}).Error
+}
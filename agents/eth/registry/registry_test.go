@@ -0,0 +1,103 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const testTokenList = `{
+	"name": "test",
+	"tokens": [
+		{"chainId": 1, "address": "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", "name": "USD Coin", "symbol": "USDC", "decimals": 6, "logoURI": "https://example.com/usdc.png"},
+		{"chainId": 1, "address": "invalid", "name": "Broken", "symbol": "BRK", "decimals": 18},
+		{"chainId": 56, "address": "0x8AC76a51cc950d9822D68b83fE1Ad97B32Cd580d", "name": "USD Coin", "symbol": "USDC", "decimals": 18}
+	]
+}`
+
+func openTestRegistry(t *testing.T) *Registry {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg, err := NewRegistry(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reg
+}
+
+func TestImportTokenList(t *testing.T) {
+	ctx := context.TODO()
+	reg := openTestRegistry(t)
+	list, err := ParseTokenList(strings.NewReader(testTokenList))
+	if err != nil {
+		t.Fatal(err)
+	}
+	currencyId := func(address string) (string, error) {
+		if !strings.HasPrefix(address, "0x") {
+			return "", errors.New("invalid address")
+		}
+		return strings.ToLower(address), nil
+	}
+	count, err := reg.ImportTokenList(ctx, "ETH:MAINNET", 1, list, currencyId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("expected 1 imported token, got %d", count)
+	}
+
+	record, err := reg.Get(ctx, "ETH:MAINNET", "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.Symbol != "USDC" || record.Decimals != 6 || record.ToProto().Metadata.IconUrl == "" {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	if record, _ := reg.Get(ctx, "BNB:MAINNET", "0x8ac76a51cc950d9822d68b83fe1ad97b32cd580d"); record != nil {
+		t.Fatal("token of other chain must not be imported")
+	}
+}
+
+func TestPutSourcePriority(t *testing.T) {
+	ctx := context.TODO()
+	reg := openTestRegistry(t)
+	chain, id := "ETH:MAINNET", "0x01"
+
+	put := func(symbol string, source Source) {
+		if err := reg.Put(ctx, &CurrencyRecord{Chain: chain, CurrencyId: id, Symbol: symbol, Decimals: 18, Source: source}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expect := func(symbol string) {
+		t.Helper()
+		record, err := reg.Get(ctx, chain, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record == nil || record.Symbol != symbol {
+			t.Fatalf("expected symbol %s, got %+v", symbol, record)
+		}
+	}
+
+	put("CHAIN", SourceChain)
+	expect("CHAIN")
+	put("LIST", SourceTokenList)
+	expect("LIST")
+	put("OVERRIDE", SourceOverride)
+	expect("OVERRIDE")
+	// on-chain data does not replace operator override
+	put("CHAIN2", SourceChain)
+	expect("OVERRIDE")
+
+	if err := reg.DeleteOverride(ctx, chain, id); err != nil {
+		t.Fatal(err)
+	}
+	put("CHAIN3", SourceChain)
+	expect("CHAIN3")
+}
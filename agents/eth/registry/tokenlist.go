@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// token entry of https://tokenlists.org format
+type TokenListToken struct {
+	ChainId  uint64 `json:"chainId"`
+	Address  string `json:"address"`
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Decimals uint32 `json:"decimals"`
+	LogoURI  string `json:"logoURI"`
+}
+
+type TokenList struct {
+	Name   string           `json:"name"`
+	Tokens []TokenListToken `json:"tokens"`
+}
+
+func ParseTokenList(r io.Reader) (*TokenList, error) {
+	var list TokenList
+	if err := json.NewDecoder(r).Decode(&list); err != nil {
+		return nil, fmt.Errorf("invalid token list: %w", err)
+	}
+	return &list, nil
+}
+
+// import tokens of the given eip-155 chain id; currencyId converts token address into registry currency id,
+// tokens with addresses it rejects are skipped. Returns number of imported tokens
+func (r *Registry) ImportTokenList(ctx context.Context, chain string, chainId uint64, list *TokenList, currencyId func(address string) (string, error)) (int, error) {
+	var records []*CurrencyRecord
+	for _, token := range list.Tokens {
+		if token.ChainId != chainId {
+			continue
+		}
+		id, err := currencyId(token.Address)
+		if err != nil {
+			continue
+		}
+		records = append(records, &CurrencyRecord{
+			Chain:      chain,
+			CurrencyId: id,
+			Symbol:     token.Symbol,
+			Name:       token.Name,
+			Decimals:   token.Decimals,
+			IconUrl:    token.LogoURI,
+			Source:     SourceTokenList,
+		})
+	}
+	if err := r.Put(ctx, records...); err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
+
+func (r *Registry) ImportTokenListFile(ctx context.Context, chain string, chainId uint64, path string, currencyId func(address string) (string, error)) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	list, err := ParseTokenList(f)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", path, err)
+	}
+	return r.ImportTokenList(ctx, chain, chainId, list, currencyId)
+}
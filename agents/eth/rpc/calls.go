@@ -3,6 +3,7 @@ package rpc
 import (
 	"math/big"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	ethtypes "github.com/ubtr/ubt-go/agents/eth/types"
@@ -28,21 +29,22 @@ func GetBlockNumber() *jsonrpc.RpcCall[uint64] {
 	)
 }
 
+// block by hash, nil response if block is unknown to the node
 func GetBlockByHash(hash common.Hash, txDetails bool) *jsonrpc.RpcCall[*ethtypes.HeaderWithBody] {
-	var res ethtypes.HeaderWithBody
-	var response *ethtypes.HeaderWithBody = &res
+	var res *ethtypes.HeaderWithBody
 	return jsonrpc.NewRpcCall[*ethtypes.HeaderWithBody](
 		"eth_getBlockByHash",
 		[]any{hash, txDetails},
 		&res,
-		&response,
+		&res,
 		nil,
 	)
 }
 
-func GetBlockByNumber(number *big.Int, txDetails bool) *jsonrpc.RpcCall[ethtypes.HeaderWithBody] {
-	var res ethtypes.HeaderWithBody
-	return jsonrpc.NewRpcCall[ethtypes.HeaderWithBody](
+// block by number or tag, nil response if node has no such block yet
+func GetBlockByNumber(number *big.Int, txDetails bool) *jsonrpc.RpcCall[*ethtypes.HeaderWithBody] {
+	var res *ethtypes.HeaderWithBody
+	return jsonrpc.NewRpcCall[*ethtypes.HeaderWithBody](
 		"eth_getBlockByNumber",
 		[]any{toBlockNumArg(number), txDetails},
 		&res,
@@ -62,3 +64,147 @@ func ChainId() *jsonrpc.RpcCall[*big.Int] {
 		nil,
 	)
 }
+
+// transaction by hash, nil response if transaction is unknown to the node
+func GetTransactionByHash(hash common.Hash) *jsonrpc.RpcCall[*ethtypes.RpcTx] {
+	var res *ethtypes.RpcTx
+	return jsonrpc.NewRpcCall[*ethtypes.RpcTx](
+		"eth_getTransactionByHash",
+		[]any{hash},
+		&res,
+		&res,
+		nil,
+	)
+}
+
+// transaction receipt, nil response if transaction is not included yet
+func GetTransactionReceipt(hash common.Hash) *jsonrpc.RpcCall[*ethtypes.RpcReceipt] {
+	var res *ethtypes.RpcReceipt
+	return jsonrpc.NewRpcCall[*ethtypes.RpcReceipt](
+		"eth_getTransactionReceipt",
+		[]any{hash},
+		&res,
+		&res,
+		nil,
+	)
+}
+
+// all receipts of the block in one call; not supported by every node
+func GetBlockReceipts(hash common.Hash) *jsonrpc.RpcCall[[]*ethtypes.RpcReceipt] {
+	var res []*ethtypes.RpcReceipt
+	return jsonrpc.NewRpcCall[[]*ethtypes.RpcReceipt](
+		"eth_getBlockReceipts",
+		[]any{hash},
+		&res,
+		&res,
+		nil,
+	)
+}
+
+// call traces of all block transactions using callTracer, requires debug namespace on the node
+func TraceBlockByHash(hash common.Hash) *jsonrpc.RpcCall[[]*ethtypes.TxTrace] {
+	var res []*ethtypes.TxTrace
+	return jsonrpc.NewRpcCall[[]*ethtypes.TxTrace](
+		"debug_traceBlockByHash",
+		[]any{hash, map[string]any{"tracer": "callTracer"}},
+		&res,
+		&res,
+		nil,
+	)
+}
+
+// native balance of the account at block, latest if number is nil
+func GetBalance(account common.Address, number *big.Int) *jsonrpc.RpcCall[*big.Int] {
+	var res hexutil.Big
+	var response *big.Int
+	return jsonrpc.NewRpcCall[*big.Int](
+		"eth_getBalance",
+		[]any{account, toBlockNumArg(number)},
+		&res,
+		&response,
+		func() error {
+			response = res.ToInt()
+			return nil
+		},
+	)
+}
+
+// eth_call at block, latest if number is nil
+func Call(msg ethereum.CallMsg, number *big.Int) *jsonrpc.RpcCall[[]byte] {
+	var res hexutil.Bytes
+	var response []byte
+	return jsonrpc.NewRpcCall[[]byte](
+		"eth_call",
+		[]any{toCallArg(msg), toBlockNumArg(number)},
+		&res,
+		&response,
+		func() error {
+			response = res
+			return nil
+		},
+	)
+}
+
+// contract code of the account at block, latest if number is nil
+func GetCode(account common.Address, number *big.Int) *jsonrpc.RpcCall[[]byte] {
+	var res hexutil.Bytes
+	var response []byte
+	return jsonrpc.NewRpcCall[[]byte](
+		"eth_getCode",
+		[]any{account, toBlockNumArg(number)},
+		&res,
+		&response,
+		func() error {
+			response = res
+			return nil
+		},
+	)
+}
+
+// nonce of the account at block, latest if number is nil
+func GetTransactionCount(account common.Address, number *big.Int) *jsonrpc.RpcCall[uint64] {
+	var res hexutil.Uint64
+	var response uint64
+	return jsonrpc.NewRpcCall[uint64](
+		"eth_getTransactionCount",
+		[]any{account, toBlockNumArg(number)},
+		&res,
+		&response,
+		func() error {
+			response = uint64(res)
+			return nil
+		},
+	)
+}
+
+// gas estimate at block, latest if number is nil
+func EstimateGas(msg ethereum.CallMsg, number *big.Int) *jsonrpc.RpcCall[uint64] {
+	var res hexutil.Uint64
+	var response uint64
+	return jsonrpc.NewRpcCall[uint64](
+		"eth_estimateGas",
+		[]any{toCallArg(msg), toBlockNumArg(number)},
+		&res,
+		&response,
+		func() error {
+			response = uint64(res)
+			return nil
+		},
+	)
+}
+
+// submit signed transaction, returns its hash
+func SendRawTransaction(rawTx []byte) *jsonrpc.RpcCall[common.Hash] {
+	var res common.Hash
+	var response common.Hash
+	return jsonrpc.NewRpcCall[common.Hash](
+		"eth_sendRawTransaction",
+		[]any{hexutil.Bytes(rawTx)},
+		&res,
+		&response,
+		func() error {
+			response = res
+			return nil
+		},
+	)
+}
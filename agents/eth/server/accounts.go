@@ -1,7 +1,21 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/agents/eth/rpc"
+	"github.com/ubtr/ubt-go/blockchain/eth"
+	"github.com/ubtr/ubt-go/blockchain/hd"
+	"github.com/ubtr/ubt-go/commons/jsonrpc"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
 	"github.com/ubtr/ubt/go/api/proto"
 	"github.com/ubtr/ubt/go/api/proto/services"
@@ -9,13 +23,132 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+var _ agent.AccountInfoProvider = (*EthServer)(nil)
+
+// EIP-7702 delegation designator: 0xef0100 followed by delegation target address
+var delegationPrefix = []byte{0xef, 0x01, 0x00}
+
+// get delegation target if the code is EIP-7702 delegation designator
+func ParseDelegation(code []byte) (common.Address, bool) {
+	if len(code) != len(delegationPrefix)+common.AddressLength || !bytes.HasPrefix(code, delegationPrefix) {
+		return common.Address{}, false
+	}
+	return common.BytesToAddress(code[len(delegationPrefix):]), true
+}
+
+// get account type with details passed in response header metadata
 func (srv *EthServer) GetAccount(ctx context.Context, req *services.GetAccountRequest) (*proto.Account, error) {
+	if req.ChainId != nil && req.ChainId.Type != srv.Chain.Type {
+		return nil, rpcerrors.ErrInvalidChainId
+	}
+	info, err := srv.GetAccountInfo(ctx, req.Address)
+	if err != nil {
+		return nil, err
+	}
+	if err := grpc.SetHeader(ctx, info.Metadata()); err != nil {
+		srv.Log.Debug("failed to set account header", "err", err)
+	}
 	return &proto.Account{
 		Id:   req.Address,
-		Type: uint32(proto.Account_STANDARD),
+		Type: info.ProtoType(),
 	}, nil
 }
+
+// code and nonce of the account fetched with one batch
+func (srv *EthServer) GetAccountInfo(ctx context.Context, address string) (*agent.AccountInfo, error) {
+	account, err := srv.AddressFromString(address)
+	if err != nil {
+		return nil, err
+	}
+	var batch jsonrpc.RpcBatch
+	codeCall := rpc.GetCode(account, nil)
+	codeCall.AddToBatch(&batch)
+	nonceCall := rpc.GetTransactionCount(account, nil)
+	nonceCall.AddToBatch(&batch)
+	if err := batch.Call(ctx, srv.C); err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get account")
+	}
+	if err := codeCall.ProcessRes(ctx); err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get account code")
+	}
+	if err := nonceCall.ProcessRes(ctx); err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get account nonce")
+	}
+	return srv.accountInfo(address, *codeCall.Response, *nonceCall.Response), nil
+}
+
+func (srv *EthServer) accountInfo(address string, code []byte, nonce uint64) *agent.AccountInfo {
+	info := &agent.AccountInfo{
+		Address:  address,
+		Kind:     agent.AccountEOA,
+		Nonce:    nonce,
+		CodeHash: crypto.Keccak256(code),
+	}
+	if target, ok := ParseDelegation(code); ok {
+		info.Kind = agent.AccountDelegated
+		info.DelegatedTo = srv.AddressToString(&target)
+	} else if len(code) > 0 {
+		info.Kind = agent.AccountContract
+	}
+	return info
+}
+
+// derive address from raw public key, or from BIP44 account level extended public key (m/44'/coin'/account')
+// given as base58 string at the index passed in metadata; the external chain (change 0) is used
 func (srv *EthServer) DeriveAccount(ctx context.Context, req *services.DeriveAccountRequest) (*proto.Account, error) {
+	if req.ChainId != nil && req.ChainId.Type != srv.Chain.Type {
+		return nil, rpcerrors.ErrInvalidChainId
+	}
+	var publicKey []byte
+	switch len(req.PublicKey) {
+	case 0:
+		return nil, rpcerrors.ArgError("publicKey", errors.New("public key is required"))
+	case 33:
+		key, err := crypto.DecompressPubkey(req.PublicKey)
+		if err != nil {
+			return nil, rpcerrors.ArgError("publicKey", err)
+		}
+		publicKey = crypto.FromECDSAPub(key)
+	case 65:
+		if _, err := crypto.UnmarshalPubkey(req.PublicKey); err != nil {
+			return nil, rpcerrors.ArgError("publicKey", err)
+		}
+		publicKey = req.PublicKey
+	default:
+		var err error
+		if publicKey, err = srv.deriveFromXpub(ctx, string(req.PublicKey)); err != nil {
+			return nil, err
+		}
+	}
+
+	address := eth.AddressFromPublicKey(publicKey)
+	return &proto.Account{
+		Id:   srv.AddressToString(&address),
+		Type: uint32(proto.Account_STANDARD),
+	}, nil
+}
 
-	return nil, status.Errorf(codes.Unimplemented, "method DeriveAccount not implemented")
+func (srv *EthServer) deriveFromXpub(ctx context.Context, xpub string) ([]byte, error) {
+	accountKey, err := hd.ParseExtendedPublicKey(xpub)
+	if err != nil {
+		return nil, rpcerrors.ArgError("publicKey", err)
+	}
+	if accountKey.Depth != hd.Bip44AccountDepth || accountKey.ChildNumber < hd.HardenedOffset {
+		return nil, rpcerrors.ArgError("publicKey", fmt.Errorf("expected account level key m/%d'/%d'/account', got depth %d", hd.Bip44Purpose, srv.Chain.TypeNum, accountKey.Depth))
+	}
+	index, err := agent.DerivationIndexFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	const change = 0
+	key, err := accountKey.Derive(change, index)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to derive index %d: %v", index, err)
+	}
+	path := hd.Bip44Path(srv.Chain.TypeNum, accountKey.ChildNumber-hd.HardenedOffset, change, index)
+	if err := grpc.SetHeader(ctx, metadata.Pairs(agent.MetadataDerivationPath, path)); err != nil {
+		// not in grpc call context
+		srv.Log.Debug("failed to set derivation path header", "err", err)
+	}
+	return key.PublicKey(), nil
 }
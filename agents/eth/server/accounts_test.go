@@ -0,0 +1,44 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt/go/api/proto"
+)
+
+func TestAccountInfoKind(t *testing.T) {
+	srv := &EthServer{}
+	target := common.HexToAddress("0x63c0c19a282a1b52b07dd5a65b58948a07dae32b")
+	delegation := append(hexutil.MustDecode("0xef0100"), target.Bytes()...)
+
+	cases := []struct {
+		name string
+		code []byte
+		kind agent.AccountKind
+		typ  uint32
+	}{
+		{"eoa", nil, agent.AccountEOA, uint32(proto.Account_STANDARD)},
+		{"contract", hexutil.MustDecode("0x6080604052"), agent.AccountContract, uint32(proto.Account_CONTRACT)},
+		{"delegated", delegation, agent.AccountDelegated, uint32(proto.Account_STANDARD) | uint32(proto.Account_CONTRACT)},
+		// designator prefix with wrong length is regular code
+		{"prefix only", hexutil.MustDecode("0xef0100"), agent.AccountContract, uint32(proto.Account_CONTRACT)},
+	}
+	for _, c := range cases {
+		info := srv.accountInfo("0x01", c.code, 7)
+		if info.Kind != c.kind || info.ProtoType() != c.typ || info.Nonce != 7 {
+			t.Errorf("%s: unexpected info %+v", c.name, info)
+		}
+	}
+
+	info := srv.accountInfo("0x01", delegation, 0)
+	if info.DelegatedTo != target.Hex() {
+		t.Fatalf("expected delegation to %s, got %s", target.Hex(), info.DelegatedTo)
+	}
+	if common.BytesToHash(srv.accountInfo("0x01", nil, 0).CodeHash) != types.EmptyCodeHash {
+		t.Fatal("expected empty code hash for eoa")
+	}
+}
@@ -0,0 +1,317 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/agents/eth/contracts/erc20"
+	"github.com/ubtr/ubt-go/agents/eth/rpc"
+	"github.com/ubtr/ubt-go/blockchain"
+	"github.com/ubtr/ubt-go/commons/jsonrpc"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"github.com/ubtr/ubt/go/api/proto"
+	"github.com/ubtr/ubt/go/api/proto/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// canonical Multicall3 deployment, same address on most evm chains
+var DefaultMulticall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+const multicall3AbiJson = `[
+	{"type":"function","name":"aggregate3","stateMutability":"payable",
+		"inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],
+		"outputs":[{"name":"returnData","type":"tuple[]","components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]},
+	{"type":"function","name":"getEthBalance","stateMutability":"view","inputs":[{"name":"addr","type":"address"}],"outputs":[{"name":"balance","type":"uint256"}]},
+	{"type":"function","name":"getBlockNumber","stateMutability":"view","inputs":[],"outputs":[{"name":"blockNumber","type":"uint256"}]}
+]`
+
+var multicall3Abi = sync.OnceValues(func() (*abi.ABI, error) {
+	parsed, err := abi.JSON(strings.NewReader(multicall3AbiJson))
+	return &parsed, err
+})
+
+type multicall3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type multicall3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// balance of the account in native currency or erc-20 token
+type BalanceQuery struct {
+	Account  common.Address
+	Currency blockchain.UChainCurrencyId
+}
+
+// state of Multicall3 deployment detection
+const (
+	multicallUnknown int32 = iota
+	multicallAvailable
+	multicallUnavailable
+)
+
+type balanceState struct {
+	multicall atomic.Int32
+}
+
+func (srv *EthServer) multicall3Address() common.Address {
+	if srv.Config.Multicall3 != "" {
+		return common.HexToAddress(srv.Config.Multicall3)
+	}
+	return DefaultMulticall3Address
+}
+
+// check once if Multicall3 is deployed
+func (srv *EthServer) multicallAvailable(ctx context.Context) bool {
+	switch srv.balances.multicall.Load() {
+	case multicallAvailable:
+		return true
+	case multicallUnavailable:
+		return false
+	}
+	code, err := rpc.AdoptClient(srv.C).CodeAt(ctx, srv.multicall3Address(), nil)
+	if err != nil {
+		srv.Log.Debug("failed to check multicall3 deployment", "err", err)
+		return false
+	}
+	if len(code) == 0 {
+		srv.Log.Info("Multicall3 is not deployed, balances are queried with json-rpc batch", "address", srv.multicall3Address())
+		srv.balances.multicall.Store(multicallUnavailable)
+		return false
+	}
+	srv.balances.multicall.Store(multicallAvailable)
+	return true
+}
+
+// get balances in one call: Multicall3 if deployed, json-rpc batch otherwise; block is nil for latest.
+// returns balances in queries order and number of the block they were read at
+func (srv *EthServer) GetBalances(ctx context.Context, queries []BalanceQuery, block *big.Int) ([]*big.Int, uint64, error) {
+	for _, q := range queries {
+		if !q.Currency.IsNative() && !q.Currency.IsErc20() {
+			return nil, 0, status.Errorf(codes.Unimplemented, "balances of %s are not supported", q.Currency.String())
+		}
+	}
+	if len(queries) > 1 && srv.multicallAvailable(ctx) {
+		balances, blockNumber, err := srv.multicallBalances(ctx, queries, block)
+		if err == nil {
+			return balances, blockNumber, nil
+		}
+		// e.g. multicall deployed after requested block
+		srv.Log.Debug("multicall balances failed, falling back to batch", "err", err)
+	}
+	return srv.batchBalances(ctx, queries, block)
+}
+
+func (srv *EthServer) tokenAddress(currency blockchain.UChainCurrencyId) (common.Address, error) {
+	return srv.AddressFromString(currency.Address)
+}
+
+func (srv *EthServer) multicallBalances(ctx context.Context, queries []BalanceQuery, block *big.Int) ([]*big.Int, uint64, error) {
+	mcAbi, err := multicall3Abi()
+	if err != nil {
+		return nil, 0, err
+	}
+	tokenAbi, err := erc20.Erc20MetaData.GetAbi()
+	if err != nil {
+		return nil, 0, err
+	}
+	mcAddress := srv.multicall3Address()
+
+	calls := make([]multicall3Call, 0, len(queries)+1)
+	for _, q := range queries {
+		if q.Currency.IsNative() {
+			data, err := mcAbi.Pack("getEthBalance", q.Account)
+			if err != nil {
+				return nil, 0, err
+			}
+			calls = append(calls, multicall3Call{Target: mcAddress, CallData: data})
+		} else {
+			token, err := srv.tokenAddress(q.Currency)
+			if err != nil {
+				return nil, 0, err
+			}
+			data, err := tokenAbi.Pack("balanceOf", q.Account)
+			if err != nil {
+				return nil, 0, err
+			}
+			calls = append(calls, multicall3Call{Target: token, AllowFailure: true, CallData: data})
+		}
+	}
+	blockNumberData, err := mcAbi.Pack("getBlockNumber")
+	if err != nil {
+		return nil, 0, err
+	}
+	calls = append(calls, multicall3Call{Target: mcAddress, CallData: blockNumberData})
+
+	input, err := mcAbi.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, 0, err
+	}
+	output, err := rpc.Call(ethereum.CallMsg{To: &mcAddress, Data: input}, block).Call(ctx, srv.C)
+	if err != nil {
+		return nil, 0, err
+	}
+	var results []multicall3Result
+	if err := mcAbi.UnpackIntoInterface(&results, "aggregate3", output); err != nil {
+		return nil, 0, err
+	}
+	if len(results) != len(calls) {
+		return nil, 0, fmt.Errorf("multicall returned %d results for %d calls", len(results), len(calls))
+	}
+
+	balances := make([]*big.Int, len(queries))
+	for i, q := range queries {
+		if !results[i].Success || len(results[i].ReturnData) < 32 {
+			return nil, 0, status.Errorf(codes.FailedPrecondition, "failed to get %s balance of %s", q.Currency.String(), srv.AddressToString(&q.Account))
+		}
+		balances[i] = new(big.Int).SetBytes(results[i].ReturnData[:32])
+	}
+	blockNumber := new(big.Int).SetBytes(results[len(queries)].ReturnData)
+	return balances, blockNumber.Uint64(), nil
+}
+
+func (srv *EthServer) batchBalances(ctx context.Context, queries []BalanceQuery, block *big.Int) ([]*big.Int, uint64, error) {
+	tokenAbi, err := erc20.Erc20MetaData.GetAbi()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var batch jsonrpc.RpcBatch
+	nativeCalls := make(map[int]*jsonrpc.RpcCall[*big.Int])
+	tokenCalls := make(map[int]*jsonrpc.RpcCall[[]byte])
+	for i, q := range queries {
+		if q.Currency.IsNative() {
+			call := rpc.GetBalance(q.Account, block)
+			call.AddToBatch(&batch)
+			nativeCalls[i] = call
+		} else {
+			token, err := srv.tokenAddress(q.Currency)
+			if err != nil {
+				return nil, 0, err
+			}
+			data, err := tokenAbi.Pack("balanceOf", q.Account)
+			if err != nil {
+				return nil, 0, err
+			}
+			call := rpc.Call(ethereum.CallMsg{To: &token, Data: data}, block)
+			call.AddToBatch(&batch)
+			tokenCalls[i] = call
+		}
+	}
+	var blockNumberCall *jsonrpc.RpcCall[uint64]
+	if block == nil {
+		blockNumberCall = rpc.GetBlockNumber()
+		blockNumberCall.AddToBatch(&batch)
+	}
+
+	if err := batch.Call(ctx, srv.C); err != nil {
+		return nil, 0, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get balances")
+	}
+
+	balances := make([]*big.Int, len(queries))
+	for i, q := range queries {
+		if call, ok := nativeCalls[i]; ok {
+			if err := call.ProcessRes(ctx); err != nil {
+				return nil, 0, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get balance")
+			}
+			balances[i] = *call.Response
+			continue
+		}
+		call := tokenCalls[i]
+		if err := call.ProcessRes(ctx); err != nil {
+			return nil, 0, rpcerrors.UpstreamError(err, codes.FailedPrecondition, fmt.Sprintf("failed to get %s balance of %s", q.Currency.String(), srv.AddressToString(&q.Account)))
+		}
+		if len(*call.Response) < 32 {
+			return nil, 0, status.Errorf(codes.FailedPrecondition, "failed to get %s balance of %s", q.Currency.String(), srv.AddressToString(&q.Account))
+		}
+		balances[i] = new(big.Int).SetBytes((*call.Response)[:32])
+	}
+
+	blockNumber := uint64(0)
+	if blockNumberCall != nil {
+		if err := blockNumberCall.ProcessRes(ctx); err != nil {
+			return nil, 0, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get block number")
+		}
+		blockNumber = *blockNumberCall.Response
+	} else {
+		blockNumber = block.Uint64()
+	}
+	return balances, blockNumber, nil
+}
+
+func (srv *EthServer) GetBalance(ctx context.Context, req *services.GetBalanceRequest) (*services.BalanceResponse, error) {
+	if req.ChainId != nil && req.ChainId.Type != srv.Chain.Type {
+		return nil, rpcerrors.ErrInvalidChainId
+	}
+	balances, blockNumber, err := srv.accountBalances(ctx, req.Address, []string{req.CurrencyId})
+	if err != nil {
+		return nil, err
+	}
+	return &services.BalanceResponse{
+		Address:    req.Address,
+		Amount:     &proto.CurrencyAmount{CurrencyId: req.CurrencyId, Value: &proto.Uint256{Data: balances[0].Bytes()}},
+		LastUpdate: blockNumber,
+	}, nil
+}
+
+// balances of the account in requested currencies, native currency if none requested
+func (srv *EthServer) ListAccountBalances(ctx context.Context, req *services.ListAccountBalancesRequest) (*services.ListAccountBalancesResponse, error) {
+	if req.ChainId != nil && req.ChainId.Type != srv.Chain.Type {
+		return nil, rpcerrors.ErrInvalidChainId
+	}
+	currencyIds := req.CurrencyIds
+	if len(currencyIds) == 0 {
+		currencyIds = []string{""}
+	}
+	balances, blockNumber, err := srv.accountBalances(ctx, req.Address, currencyIds)
+	if err != nil {
+		return nil, err
+	}
+	srv.Log.Debug("Balances", "account", req.Address, "count", len(balances), "block", blockNumber)
+
+	res := &services.ListAccountBalancesResponse{}
+	for i, balance := range balances {
+		res.Amounts = append(res.Amounts, &proto.CurrencyAmount{CurrencyId: currencyIds[i], Value: &proto.Uint256{Data: balance.Bytes()}})
+		currency, err := srv.GetCurrency(ctx, &services.GetCurrencyRequest{Id: currencyIds[i]})
+		if err != nil {
+			srv.Log.Debug("failed to get currency", "currencyId", currencyIds[i], "err", err)
+			continue
+		}
+		res.Currencies = append(res.Currencies, currency)
+	}
+	return res, nil
+}
+
+// balances of one account at block requested in metadata
+func (srv *EthServer) accountBalances(ctx context.Context, address string, currencyIds []string) ([]*big.Int, uint64, error) {
+	account, err := srv.AddressFromString(address)
+	if err != nil {
+		return nil, 0, err
+	}
+	block, err := agent.BlockNumberFromContext(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	queries := make([]BalanceQuery, len(currencyIds))
+	for i, id := range currencyIds {
+		currency, err := blockchain.UChainCurrencyIdromString(id)
+		if err != nil {
+			return nil, 0, err
+		}
+		queries[i] = BalanceQuery{Account: account, Currency: currency}
+	}
+	return srv.GetBalances(ctx, queries, block)
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ubtr/ubt-go/agents/eth/contracts/erc20"
+	"github.com/ubtr/ubt-go/blockchain"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMulticall3Encoding(t *testing.T) {
+	mcAbi, err := multicall3Abi()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input, err := mcAbi.Pack("aggregate3", []multicall3Call{{Target: DefaultMulticall3Address, CallData: []byte{1, 2, 3, 4}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// aggregate3((address,bool,bytes)[])
+	if selector := hex.EncodeToString(input[:4]); selector != "82ad56cb" {
+		t.Fatalf("unexpected aggregate3 selector %s", selector)
+	}
+
+	balance := common.LeftPadBytes(big.NewInt(1000).Bytes(), 32)
+	output, err := mcAbi.Methods["aggregate3"].Outputs.Pack([]multicall3Result{{Success: true, ReturnData: balance}, {Success: false}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []multicall3Result
+	if err := mcAbi.UnpackIntoInterface(&results, "aggregate3", output); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || !results[0].Success || results[1].Success {
+		t.Fatalf("unexpected results %+v", results)
+	}
+	if new(big.Int).SetBytes(results[0].ReturnData).Int64() != 1000 {
+		t.Fatalf("unexpected balance %x", results[0].ReturnData)
+	}
+}
+
+// stub of Multicall3 and erc-20 tokens served by eth_call; native balances by eth_getBalance
+type testBalances struct {
+	t           *testing.T
+	native      map[common.Address]int64
+	tokens      map[common.Address]map[common.Address]int64 // token -> account -> balance
+	short       map[common.Address]bool                     // tokens returning less than 32 bytes
+	mcFails     map[common.Address]bool                     // tokens failing when called from Multicall3
+	blockNumber uint64                                      // block of multicall getBlockNumber
+}
+
+func (b *testBalances) tokenCall(token common.Address, data []byte, fromMulticall bool) ([]byte, bool) {
+	tokenAbi, err := erc20.Erc20MetaData.GetAbi()
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	args, err := tokenAbi.Methods["balanceOf"].Inputs.Unpack(data[4:])
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	if b.short[token] {
+		return []byte{1}, true
+	}
+	if fromMulticall && b.mcFails[token] {
+		return nil, false
+	}
+	balance := b.tokens[token][args[0].(common.Address)]
+	return common.LeftPadBytes(big.NewInt(balance).Bytes(), 32), true
+}
+
+func (b *testBalances) aggregate3(data []byte) []byte {
+	mcAbi, err := multicall3Abi()
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	args, err := mcAbi.Methods["aggregate3"].Inputs.Unpack(data[4:])
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	calls := *abi.ConvertType(args[0], new([]multicall3Call)).(*[]multicall3Call)
+	results := make([]multicall3Result, len(calls))
+	for i, call := range calls {
+		if call.Target != DefaultMulticall3Address {
+			results[i].ReturnData, results[i].Success = b.tokenCall(call.Target, call.CallData, true)
+			continue
+		}
+		method, err := mcAbi.MethodById(call.CallData)
+		if err != nil {
+			b.t.Fatal(err)
+		}
+		var value *big.Int
+		if method.Name == "getBlockNumber" {
+			value = new(big.Int).SetUint64(b.blockNumber)
+		} else {
+			account, err := method.Inputs.Unpack(call.CallData[4:])
+			if err != nil {
+				b.t.Fatal(err)
+			}
+			value = big.NewInt(b.native[account[0].(common.Address)])
+		}
+		results[i] = multicall3Result{Success: true, ReturnData: common.LeftPadBytes(value.Bytes(), 32)}
+	}
+	output, err := mcAbi.Methods["aggregate3"].Outputs.Pack(results)
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	return output
+}
+
+func (b *testBalances) Results(multicall bool) map[string]any {
+	code := "0x"
+	if multicall {
+		code = "0x01"
+	}
+	return map[string]any{
+		"eth_getCode":     code,
+		"eth_blockNumber": "0x10",
+		"eth_getBalance": testRpcHandler(func(params []json.RawMessage) any {
+			var account common.Address
+			json.Unmarshal(params[0], &account)
+			return (*hexutil.Big)(big.NewInt(b.native[account]))
+		}),
+		"eth_call": testRpcHandler(func(params []json.RawMessage) any {
+			var msg struct {
+				To   common.Address `json:"to"`
+				Data hexutil.Bytes  `json:"data"`
+			}
+			json.Unmarshal(params[0], &msg)
+			if msg.To == DefaultMulticall3Address {
+				return hexutil.Bytes(b.aggregate3(msg.Data))
+			}
+			output, _ := b.tokenCall(msg.To, msg.Data, false)
+			return hexutil.Bytes(output)
+		}),
+	}
+}
+
+func TestGetBalances(t *testing.T) {
+	acc1 := common.HexToAddress("0x01")
+	acc2 := common.HexToAddress("0x02")
+	tokenA := common.HexToAddress("0x0a")
+	tokenB := common.HexToAddress("0x0b")
+	stub := &testBalances{
+		t:      t,
+		native: map[common.Address]int64{acc1: 100, acc2: 200},
+		tokens: map[common.Address]map[common.Address]int64{
+			tokenA: {acc1: 1, acc2: 2},
+			tokenB: {acc1: 10, acc2: 20},
+		},
+		blockNumber: 77,
+	}
+	token := func(addr common.Address) blockchain.UChainCurrencyId {
+		return blockchain.UChainCurrencyId{Address: addr.Hex()}
+	}
+	queries := []BalanceQuery{
+		{Account: acc2, Currency: token(tokenB)},
+		{Account: acc1, Currency: blockchain.NATIVE_CURRENCY},
+		{Account: acc1, Currency: token(tokenA)},
+		{Account: acc2, Currency: blockchain.NATIVE_CURRENCY},
+	}
+	expected := []int64{20, 100, 1, 200}
+
+	check := func(name string, multicall bool, expectedBlock uint64) {
+		t.Helper()
+		srv := newTestServer(t, stub.Results(multicall))
+		balances, blockNumber, err := srv.GetBalances(context.Background(), queries, nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for i, balance := range balances {
+			if balance.Int64() != expected[i] {
+				t.Errorf("%s: query %d: expected %d, got %s", name, i, expected[i], balance)
+			}
+		}
+		if blockNumber != expectedBlock {
+			t.Errorf("%s: expected block %d, got %d", name, expectedBlock, blockNumber)
+		}
+	}
+	// block number is read by multicall itself, batch gets it from eth_blockNumber
+	check("multicall", true, 77)
+	check("batch", false, 16)
+
+	stub.mcFails = map[common.Address]bool{tokenA: true}
+	check("multicall fallback", true, 16)
+
+	stub.short = map[common.Address]bool{tokenB: true}
+	srv := newTestServer(t, stub.Results(false))
+	_, _, err := srv.GetBalances(context.Background(), queries, nil)
+	if status.Code(err) != codes.FailedPrecondition || strings.Contains(err.Error(), "<nil>") {
+		t.Errorf("expected failed precondition for short balance, got %v", err)
+	}
+}
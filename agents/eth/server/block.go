@@ -2,14 +2,20 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
 	"github.com/ubtr/ubt-go/agent"
 	"github.com/ubtr/ubt-go/agents/eth/rpc"
 	ethtypes "github.com/ubtr/ubt-go/agents/eth/types"
+	"github.com/ubtr/ubt-go/commons/jsonrpc"
 	"github.com/ubtr/ubt-go/commons/jsonrpc/client"
 	"github.com/ubtr/ubt/go/api/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -23,19 +29,16 @@ type BlockConverter struct {
 	Log    *slog.Logger
 }
 
-const slotTimeSec = uint64(12)
-
 func (srv *BlockConverter) getBlockFinalityStatus(block *proto.Block) proto.FinalityStatus {
 	if srv.Srv.Extensions.BlockFinalityStatus != nil {
 		return srv.Srv.Extensions.BlockFinalityStatus(block)
 	}
-	if uint64(block.Header.Timestamp.Seconds) < uint64(time.Now().Unix())-54*slotTimeSec {
-		return proto.FinalityStatus_FINALITY_STATUS_FINALIZED
-	} else if uint64(block.Header.Timestamp.Seconds) < uint64(time.Now().Unix())-32*slotTimeSec {
-		return proto.FinalityStatus_FINALITY_STATUS_SAFE
-	} else {
+	heads, err := srv.Srv.Heads.Heads(srv.Ctx)
+	if err != nil {
+		srv.Log.Warn("failed to get chain heads, block considered unsafe", "err", err)
 		return proto.FinalityStatus_FINALITY_STATUS_UNSAFE
 	}
+	return heads.Status(block.Header.Number)
 }
 
 func (c *BlockConverter) loadAndGroupLogs(block *ethtypes.HeaderWithBody) (map[uint][]types.Log, error) {
@@ -61,6 +64,90 @@ func (c *BlockConverter) loadAndGroupLogs(block *ethtypes.HeaderWithBody) (map[u
 	return res, nil
 }
 
+// load receipts of all block transactions keyed by transaction index;
+// eth_getBlockReceipts is used if upstream supports it, otherwise receipts are requested per transaction in one batch
+func (c *BlockConverter) loadReceipts(block *ethtypes.HeaderWithBody) (map[uint]*ethtypes.RpcReceipt, error) {
+	res := make(map[uint]*ethtypes.RpcReceipt, len(block.Body.Transactions))
+	if len(block.Body.Transactions) == 0 {
+		return res, nil
+	}
+
+	if !c.Srv.blockReceiptsUnsupported.Load() {
+		receipts, err := rpc.GetBlockReceipts(block.BlockHash).Call(c.Ctx, c.Client)
+		if err == nil && len(receipts) == len(block.Body.Transactions) {
+			for _, receipt := range receipts {
+				res[uint(receipt.TransactionIndex)] = receipt
+			}
+			return res, nil
+		}
+		if err != nil && isMethodNotFound(err) {
+			c.Log.Info("eth_getBlockReceipts is not supported, falling back to per transaction receipts", "err", err)
+			c.Srv.blockReceiptsUnsupported.Store(true)
+		} else {
+			c.Log.Warn("Failed to load block receipts, falling back to per transaction receipts", "err", err, "count", len(receipts))
+		}
+	}
+
+	var batch jsonrpc.RpcBatch
+	calls := make([]*jsonrpc.RpcCall[*ethtypes.RpcReceipt], 0, len(block.Body.Transactions))
+	for _, tx := range block.Body.Transactions {
+		call := rpc.GetTransactionReceipt(tx.TxHash)
+		call.AddToBatch(&batch)
+		calls = append(calls, call)
+	}
+	if err := batch.Call(c.Ctx, c.Client); err != nil {
+		return nil, err
+	}
+	for _, call := range calls {
+		if err := call.ProcessRes(c.Ctx); err != nil {
+			return nil, err
+		}
+		receipt := *call.Response
+		if receipt != nil {
+			res[uint(receipt.TransactionIndex)] = receipt
+		}
+	}
+	return res, nil
+}
+
+// load call traces of block transactions keyed by transaction hash, nil if tracing is disabled
+func (c *BlockConverter) loadTraces(block *ethtypes.HeaderWithBody) (map[common.Hash]*ethtypes.CallFrame, error) {
+	if c.Srv.TraceC == nil || len(block.Body.Transactions) == 0 {
+		return nil, nil
+	}
+	traces, err := rpc.TraceBlockByHash(block.BlockHash).Call(c.Ctx, c.Srv.TraceC)
+	if err != nil {
+		c.Log.Error("Failed to trace block", "err", err)
+		return nil, err
+	}
+	if len(traces) != len(block.Body.Transactions) {
+		return nil, fmt.Errorf("block %s traces count %d does not match transactions count %d", block.BlockHash, len(traces), len(block.Body.Transactions))
+	}
+	res := make(map[common.Hash]*ethtypes.CallFrame, len(traces))
+	for i, trace := range traces {
+		if trace.Error != "" {
+			return nil, fmt.Errorf("failed to trace tx %s: %s", block.Body.Transactions[i].TxHash, trace.Error)
+		}
+		// older nodes do not report tx hash, traces are in transactions order
+		txHash := block.Body.Transactions[i].TxHash
+		if trace.TxHash != nil {
+			txHash = *trace.TxHash
+		}
+		res[txHash] = trace.Result
+	}
+	return res, nil
+}
+
+// check if upstream rejected call because method is not available
+func isMethodNotFound(err error) bool {
+	var rpcErr gethrpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == -32601 {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "method not found") || strings.Contains(msg, "does not exist") || strings.Contains(msg, "not supported")
+}
+
 func (c *BlockConverter) EthBlockToProto(block *ethtypes.HeaderWithBody) (*proto.Block, error) {
 	ret := &proto.Block{
 		Header: &proto.BlockHeader{
@@ -78,14 +165,34 @@ func (c *BlockConverter) EthBlockToProto(block *ethtypes.HeaderWithBody) (*proto
 		return nil, err
 	}
 
+	receipts, err := c.loadReceipts(block)
+	if err != nil {
+		return nil, err
+	}
+
+	traces, err := c.loadTraces(block)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, tx := range block.Body.Transactions {
-		txConverter := &TxConverter{Srv: c.Srv, Log: c.Log.With("txId", tx.Tx.Hash().String(), "txIndex", uint64(tx.TransactionIndex))}
-		txProto, err := txConverter.Convert(tx, logs[uint(tx.TransactionIndex)])
+		txConverter := &TxConverter{Srv: c.Srv, Trace: traces[tx.TxHash], BaseFee: block.Header.BaseFee, Log: c.Log.With("txId", tx.Tx.Hash().String(), "txIndex", uint64(tx.TransactionIndex))}
+		txProto, err := txConverter.Convert(tx, logs[uint(tx.TransactionIndex)], receipts[uint(tx.TransactionIndex)])
 		if err != nil {
 			return nil, err
 		}
 		ret.Transactions = append(ret.Transactions, txProto)
 	}
 
+	if withdrawals := c.ConvertWithdrawals(block, uint32(len(ret.Transactions))); withdrawals != nil {
+		ret.Transactions = append(ret.Transactions, withdrawals)
+	}
+
+	if c.Config.FeeRecipientIncome {
+		if rewards := c.ConvertBlockRewards(block, receipts, uint32(len(ret.Transactions))); rewards != nil {
+			ret.Transactions = append(ret.Transactions, rewards)
+		}
+	}
+
 	return ret, nil
 }
@@ -0,0 +1,20 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ubtr/ubt/go/api/proto/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetUnknownBlock(t *testing.T) {
+	srv := newTestServer(t, map[string]any{"eth_getBlockByHash": nil})
+
+	_, err := srv.GetBlock(context.Background(), &services.BlockRequest{Id: common.HexToHash("0x01").Bytes()})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
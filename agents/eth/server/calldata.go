@@ -0,0 +1,215 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+)
+
+// "uint" and "int" are aliases of 256 bits types, also as element types of arrays
+var intAliasRe = regexp.MustCompile(`^(u?int)($|\[)`)
+
+// parse method signature like "transfer(address,uint256)" into name and argument types; tuples are not supported
+func ParseMethodSignature(signature string) (string, abi.Arguments, error) {
+	signature = strings.TrimSpace(signature)
+	open := strings.Index(signature, "(")
+	if open <= 0 || !strings.HasSuffix(signature, ")") {
+		return "", nil, fmt.Errorf("invalid method signature '%s'", signature)
+	}
+	name := signature[:open]
+	typesStr := strings.TrimSpace(signature[open+1 : len(signature)-1])
+	if strings.ContainsAny(typesStr, "()") {
+		return "", nil, fmt.Errorf("tuple arguments are not supported in '%s'", signature)
+	}
+
+	var args abi.Arguments
+	if typesStr == "" {
+		return name, args, nil
+	}
+	for _, typeStr := range strings.Split(typesStr, ",") {
+		// drop argument name if given, e.g. "address to"
+		fields := strings.Fields(typeStr)
+		if len(fields) == 0 {
+			return "", nil, fmt.Errorf("empty argument type in '%s'", signature)
+		}
+		t, err := abi.NewType(intAliasRe.ReplaceAllString(fields[0], "${1}256${2}"), "", nil)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, abi.Argument{Type: t})
+	}
+	return name, args, nil
+}
+
+// encode method call: 4 bytes selector of canonical signature followed by abi encoded arguments given as json array;
+// addresses are parsed with chain specific parser
+func EncodeMethodCall(signature string, jsonArgs json.RawMessage, parseAddress func(string) (common.Address, error)) ([]byte, error) {
+	name, args, err := ParseMethodSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	var rawArgs []json.RawMessage
+	if len(jsonArgs) > 0 {
+		if err := json.Unmarshal(jsonArgs, &rawArgs); err != nil {
+			return nil, fmt.Errorf("arguments must be json array: %w", err)
+		}
+	}
+	if len(rawArgs) != len(args) {
+		return nil, fmt.Errorf("method %s expects %d arguments, got %d", name, len(args), len(rawArgs))
+	}
+
+	typeNames := make([]string, len(args))
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		typeNames[i] = arg.Type.String()
+		value, err := jsonToAbiValue(arg.Type, rawArgs[i], parseAddress)
+		if err != nil {
+			return nil, fmt.Errorf("argument %d: %w", i, err)
+		}
+		values[i] = value.Interface()
+	}
+
+	packed, err := args.Pack(values...)
+	if err != nil {
+		return nil, err
+	}
+	selector := crypto.Keccak256([]byte(name + "(" + strings.Join(typeNames, ",") + ")"))[:4]
+	return append(selector, packed...), nil
+}
+
+// convert json value into go value of the type expected by abi packer
+func jsonToAbiValue(t abi.Type, raw json.RawMessage, parseAddress func(string) (common.Address, error)) (reflect.Value, error) {
+	goType := t.GetType()
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		var num json.Number
+		if err := json.Unmarshal(raw, &num); err != nil {
+			// numbers may be given as strings to keep precision
+			var str string
+			if err := json.Unmarshal(raw, &str); err != nil {
+				return reflect.Value{}, err
+			}
+			num = json.Number(str)
+		}
+		value, ok := new(big.Int).SetString(num.String(), 0)
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("invalid integer '%s'", num)
+		}
+		if t.T == abi.UintTy && (value.Sign() < 0 || value.BitLen() > t.Size) {
+			return reflect.Value{}, fmt.Errorf("integer %s overflows %s", value, t)
+		}
+		if t.T == abi.IntTy {
+			magnitude := value
+			if value.Sign() < 0 {
+				// -2^(n-1) fits while 2^(n-1) does not
+				magnitude = new(big.Int).Add(value, big.NewInt(1))
+			}
+			if magnitude.BitLen() > t.Size-1 {
+				return reflect.Value{}, fmt.Errorf("integer %s overflows %s", value, t)
+			}
+		}
+		if goType == reflect.TypeOf(value) {
+			return reflect.ValueOf(value), nil
+		}
+		ret := reflect.New(goType).Elem()
+		if t.T == abi.IntTy {
+			if !value.IsInt64() || ret.OverflowInt(value.Int64()) {
+				return reflect.Value{}, fmt.Errorf("integer %s overflows %s", value, t)
+			}
+			ret.SetInt(value.Int64())
+		} else {
+			if value.Sign() < 0 || !value.IsUint64() || ret.OverflowUint(value.Uint64()) {
+				return reflect.Value{}, fmt.Errorf("integer %s overflows %s", value, t)
+			}
+			ret.SetUint(value.Uint64())
+		}
+		return ret, nil
+	case abi.BoolTy:
+		var b bool
+		err := json.Unmarshal(raw, &b)
+		return reflect.ValueOf(b), err
+	case abi.StringTy:
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return reflect.ValueOf(s), err
+	case abi.AddressTy:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return reflect.Value{}, err
+		}
+		addr, err := parseAddress(s)
+		return reflect.ValueOf(addr), err
+	case abi.BytesTy, abi.FixedBytesTy:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return reflect.Value{}, err
+		}
+		data, err := hexutil.Decode(s)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if t.T == abi.BytesTy {
+			return reflect.ValueOf(data), nil
+		}
+		if len(data) > t.Size {
+			return reflect.Value{}, fmt.Errorf("value of %d bytes overflows %s", len(data), t)
+		}
+		ret := reflect.New(goType).Elem()
+		reflect.Copy(ret, reflect.ValueOf(data))
+		return ret, nil
+	case abi.SliceTy, abi.ArrayTy:
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return reflect.Value{}, err
+		}
+		var ret reflect.Value
+		if t.T == abi.SliceTy {
+			ret = reflect.MakeSlice(goType, len(items), len(items))
+		} else {
+			if len(items) != t.Size {
+				return reflect.Value{}, fmt.Errorf("%s expects %d items, got %d", t, t.Size, len(items))
+			}
+			ret = reflect.New(goType).Elem()
+		}
+		for i, item := range items {
+			value, err := jsonToAbiValue(*t.Elem, item, parseAddress)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			ret.Index(i).Set(value)
+		}
+		return ret, nil
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported argument type %s", t)
+	}
+}
+
+// get calldata of the contract call request
+func (srv *EthServer) ContractCallData(req *agent.ContractCallRequest) ([]byte, error) {
+	if len(req.Data) > 0 {
+		if req.Method != "" {
+			return nil, rpcerrors.ArgError("method", errors.New("either data or method must be set"))
+		}
+		return req.Data, nil
+	}
+	if req.Method == "" {
+		// plain value transfer to contract
+		return nil, nil
+	}
+	data, err := EncodeMethodCall(req.Method, req.Args, srv.AddressFromString)
+	if err != nil {
+		return nil, rpcerrors.ArgError("args", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ubtr/ubt-go/agents/eth/contracts/erc20"
+)
+
+func TestEncodeMethodCall(t *testing.T) {
+	srv := &EthServer{}
+	to := common.HexToAddress("0x0000000000000000000000000000000000000001")
+
+	data, err := EncodeMethodCall("transfer(address to, uint256 amount)", json.RawMessage(`["0x0000000000000000000000000000000000000001", "1000"]`), srv.AddressFromString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := erc20.Erc20MetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := parsed.Pack("transfer", to, big.NewInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, expected) {
+		t.Errorf("expected %x, got %x", expected, data)
+	}
+
+	// small ints, fixed bytes and arrays are converted to exact go types
+	if _, err := EncodeMethodCall("f(uint8,bytes4,address[],bool)", json.RawMessage(`[7, "0x01020304", ["0x0000000000000000000000000000000000000001"], true]`), srv.AddressFromString); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// signed bounds and aliases of 256 bits types
+	for _, c := range []struct {
+		method string
+		args   string
+	}{
+		{"f(int128)", `["0x7fffffffffffffffffffffffffffffff"]`},
+		{"f(int128)", `["-0x80000000000000000000000000000000"]`},
+		{"f(int256)", `["0x7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff"]`},
+		{"f(int256)", `["-0x8000000000000000000000000000000000000000000000000000000000000000"]`},
+		{"f(int72)", `["-0x800000000000000000"]`},
+		{"f(uint,int)", `[1, -1]`},
+		{"f(uint[2])", `[[1, 2]]`},
+	} {
+		if _, err := EncodeMethodCall(c.method, json.RawMessage(c.args), srv.AddressFromString); err != nil {
+			t.Errorf("%s %s: unexpected error: %v", c.method, c.args, err)
+		}
+	}
+	alias, err := EncodeMethodCall("transfer(address,uint)", json.RawMessage(`["0x0000000000000000000000000000000000000001", "1000"]`), srv.AddressFromString)
+	if err != nil || !bytes.Equal(alias, expected) {
+		t.Errorf("expected %x, got %x (%v)", expected, alias, err)
+	}
+
+	for _, c := range []struct {
+		method string
+		args   string
+	}{
+		{"f(int128)", `["0x80000000000000000000000000000000"]`},
+		{"f(int128)", `["-0x80000000000000000000000000000001"]`},
+		{"f(int128)", `["0x100000000000000000000000000000000000000000000000000"]`},
+		{"f(int256)", `["0x8000000000000000000000000000000000000000000000000000000000000000"]`},
+		{"f(int256)", `["-0x8000000000000000000000000000000000000000000000000000000000000001"]`},
+		{"f(int256)", `["-0x10000000000000000000000000000000000000000000000000000000000000000000000"]`},
+		{"f(int72)", `["0x1000000000000000000"]`},
+		{"transfer(address,uint256)", `["0x0000000000000000000000000000000000000001"]`},
+		{"f(uint8)", `[256]`},
+		{"f((uint256,uint256))", `[]`},
+		{"transfer", `[]`},
+		{"f(uint256,)", `[1, 2]`},
+		{"f(uint256, ,bool)", `[1, 2, true]`},
+		{"f(,uint256)", `[1, 2]`},
+		{"f(uint256)", `[-1]`},
+		{"f(uint128)", `["-0x01"]`},
+		{"f(uint8)", `[-1]`},
+		{"f(uint72)", `["0x1000000000000000000"]`},
+	} {
+		if _, err := EncodeMethodCall(c.method, json.RawMessage(c.args), srv.AddressFromString); err == nil {
+			t.Errorf("%s %s: expected error", c.method, c.args)
+		}
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ubtr/ubt-go/agent"
 	"github.com/ubtr/ubt-go/agents/eth/rpc"
 	"github.com/ubtr/ubt-go/blockchain"
 	"github.com/ubtr/ubt-go/blockchain/eth"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
 	"github.com/ubtr/ubt/go/api/proto"
 	"github.com/ubtr/ubt/go/api/proto/services"
 	"golang.org/x/crypto/sha3"
@@ -19,14 +21,14 @@ import (
 
 func (srv *EthServer) CreateTransfer(ctx context.Context, req *services.CreateTransferRequest) (*services.TransactionIntent, error) {
 
-	nonce, err := rpc.AdoptClient(srv.C).PendingNonceAt(ctx, common.HexToAddress(req.From))
+	feeOpts, err := agent.FeeOptionsFromContext(ctx, &srv.Config)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get nonce: %v", err)
+		return nil, err
 	}
 
-	gasPrice, err := rpc.AdoptClient(srv.C).SuggestGasPrice(ctx)
+	fees, err := srv.SuggestFees(ctx, feeOpts)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to get gas price: %v", err)
+		return nil, err
 	}
 
 	currencyId, err := blockchain.UChainCurrencyIdromString(req.Amount.CurrencyId)
@@ -45,11 +47,14 @@ func (srv *EthServer) CreateTransfer(ctx context.Context, req *services.CreateTr
 
 	var gasEstimate uint64 = 21000 // native transfer
 
-	var tx *types.Transaction
+	var txTo common.Address
+	var value *big.Int
+	var data []byte
 
 	if currencyId.IsNative() {
-		tx = types.NewTransaction(nonce, toAddress, big.NewInt(0).SetBytes(req.Amount.Value.Data), uint64(21000), gasPrice, nil)
-		srv.Log.Debug("transfer native", "tx", tx)
+		txTo = toAddress
+		value = big.NewInt(0).SetBytes(req.Amount.Value.Data)
+		srv.Log.Debug("transfer native", "to", txTo, "value", value)
 	} else if currencyId.IsErc20() {
 		transferFnSignature := []byte("transfer(address,uint256)")
 		hash := sha3.NewLegacyKeccak256()
@@ -63,7 +68,6 @@ func (srv *EthServer) CreateTransfer(ctx context.Context, req *services.CreateTr
 
 		paddedAddress := common.LeftPadBytes(toAddress.Bytes(), 32)
 		paddedAmount := common.LeftPadBytes(big.NewInt(0).SetBytes(req.Amount.Value.Data).Bytes(), 32)
-		var data []byte
 		data = append(data, methodID...)
 		data = append(data, paddedAddress...)
 		data = append(data, paddedAmount...)
@@ -74,19 +78,92 @@ func (srv *EthServer) CreateTransfer(ctx context.Context, req *services.CreateTr
 			Data: data,
 		})
 		if err != nil {
-			return nil, status.Errorf(codes.Internal, "failed to estimate gas: %v", err)
+			return nil, rpcerrors.UpstreamError(err, codes.Internal, "failed to estimate gas")
 		}
 		gasEstimate = gasLimit
+		txTo = tokenAddress
+		value = big.NewInt(0)
+		srv.Log.Debug("transfer erc20", "token", txTo, "data", data)
+	} else if currencyId.IsErc1155() {
+		tokenAddress, err := srv.AddressFromString(currencyId.Address)
+		if err != nil {
+			return nil, err
+		}
+		tokenId, ok := new(big.Int).SetString(currencyId.Token, 10)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid token id: %s", currencyId.Token)
+		}
+		amount := big.NewInt(0).SetBytes(req.Amount.Value.Data)
+		isErc721, err := srv.IsErc721(ctx, tokenAddress)
+		if err != nil {
+			return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to detect token standard")
+		}
+		if isErc721 {
+			if amount.Cmp(big.NewInt(1)) != 0 {
+				return nil, status.Errorf(codes.InvalidArgument, "erc721 transfer amount must be 1, got %s", amount)
+			}
+			parsed, err := erc721Abi()
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to parse erc721 abi: %v", err)
+			}
+			data, err = parsed.Pack("safeTransferFrom", fromAddress, toAddress, tokenId)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to pack safeTransferFrom: %v", err)
+			}
+		} else {
+			parsed, err := erc1155Abi()
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to parse erc1155 abi: %v", err)
+			}
+			data, err = parsed.Pack("safeTransferFrom", fromAddress, toAddress, tokenId, amount, []byte{})
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to pack safeTransferFrom: %v", err)
+			}
+		}
 
-		tx = types.NewTransaction(nonce, tokenAddress, big.NewInt(0), gasLimit, gasPrice, data)
-		srv.Log.Debug("transfer erc20", "tx", tx)
+		gasLimit, err := rpc.AdoptClient(srv.C).EstimateGas(ctx, ethereum.CallMsg{
+			From: fromAddress,
+			To:   &tokenAddress,
+			Data: data,
+		})
+		if err != nil {
+			return nil, rpcerrors.UpstreamError(err, codes.Internal, "failed to estimate gas")
+		}
+		gasEstimate = gasLimit
+		txTo = tokenAddress
+		value = big.NewInt(0)
+		srv.Log.Debug("transfer nft", "token", txTo, "tokenId", tokenId, "erc721", isErc721, "data", data)
 	} else {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid currency id: %s", req.Amount.CurrencyId)
 	}
 
-	srv.Log.Debug("Estimating", "gasPrice", gasPrice, "gasEstimate", gasEstimate)
+	return srv.newIntent(ctx, fees, feeOpts, fromAddress, &txTo, value, gasEstimate, data)
+}
+
+// reserve nonce and wrap transaction with given fees into intent; to is nil for contract deployment
+func (srv *EthServer) newIntent(ctx context.Context, fees *TxFees, feeOpts agent.FeeOptions, from common.Address, to *common.Address, value *big.Int, gas uint64, data []byte) (*services.TransactionIntent, error) {
+	nonce, err := srv.ReserveNonce(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := fees.NewTx(srv.ChainId, nonce, to, value, gas, data)
 
-	txId := types.NewEIP155Signer(srv.ChainId).Hash(tx)
+	expectedFee := fees.ExpectedFee(gas)
+	srv.Log.Debug("Estimating", "speed", feeOpts.Speed, "dynamic", fees.IsDynamic(), "gasPrice", fees.ExpectedGasPrice(), "gasEstimate", gas,
+		"expectedFee", expectedFee, "maxFee", fees.MaxFee(gas), "nonce", nonce)
+
+	intent, err := srv.txToIntent(tx, expectedFee)
+	if err != nil {
+		srv.ReleaseNonce(ctx, from, nonce)
+		return nil, err
+	}
+	return intent, nil
+}
+
+// wrap unsigned transaction into intent
+func (srv *EthServer) txToIntent(tx *types.Transaction, estimatedFee *big.Int) (*services.TransactionIntent, error) {
+	txId := srv.Signer().Hash(tx)
 
 	srv.Log.Debug("calculating txId", "txId", txId)
 	rawTx, err := tx.MarshalBinary()
@@ -94,26 +171,15 @@ func (srv *EthServer) CreateTransfer(ctx context.Context, req *services.CreateTr
 		return nil, status.Errorf(codes.Internal, "failed to marshal tx: %v", err)
 	}
 
-	gasCost := big.NewInt(0).Mul(big.NewInt(0).SetUint64(gasEstimate), gasPrice)
-
-	intent := &services.TransactionIntent{
+	return &services.TransactionIntent{
 		Id:            txId.Bytes(),
 		PayloadToSign: txId.Bytes(),
 		SignatureType: eth.Instance.SignatureType,
 		RawData:       rawTx,
-		EstimatedFee:  &proto.Uint256{Data: gasCost.Bytes()},
-	}
-
-	return intent, nil
-
-	//tx := types.NewTransaction(nonce, common.HexToAddress(req.To), big.NewInt(12400000), 10000000, big.NewInt(0), nil)
-	//tx2, err := types.SignTx(tx, types.NewEIP155Signer(big.NewInt(1)), nil)
-	//tx2.
-	//(types.NewEIP155Signer(big.NewInt(1)))
-	//srv.C.EstimateGas(ctx, tx)
-
-	//return nil, status.Errorf(codes.Unimplemented, "method CreateTransfer not implemented")
+		EstimatedFee:  &proto.Uint256{Data: estimatedFee.Bytes()},
+	}, nil
 }
+
 func (srv *EthServer) CombineTransaction(ctx context.Context, req *services.TransactionCombineRequest) (*services.SignedTransaction, error) {
 	return &services.SignedTransaction{
 		Intent:     req.Intent,
@@ -140,15 +206,22 @@ func (srv *EthServer) Send(ctx context.Context, req *services.TransactionSendReq
 		return nil, status.Errorf(codes.Internal, "failed to unmarshal raw tx: %v", err)
 	}
 
-	tx, err = tx.WithSignature(types.NewEIP155Signer(srv.ChainId), req.Signatures[0])
+	tx, err = tx.WithSignature(srv.Signer(), req.Signatures[0])
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to sign tx: %v", err)
 	}
 
-	srv.Log.Debug("sendTx", "tx", tx)
-	err = rpc.AdoptClient(srv.C).SendTransaction(ctx, tx)
+	dryRun, err := agent.DryRunFromContext(ctx)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to send tx: %v", err)
+		return nil, err
+	}
+	if dryRun {
+		return srv.dryRunSend(ctx, tx)
+	}
+
+	srv.Log.Debug("sendTx", "tx", tx)
+	if err := srv.sendRawTx(ctx, tx); err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Internal, "failed to send tx")
 	}
 
 	return &services.TransactionSendResponse{
@@ -156,3 +229,51 @@ func (srv *EthServer) Send(ctx context.Context, req *services.TransactionSendReq
 	}, nil
 
 }
+
+// submit signed tx to one or, if configured, every upstream; resending the same tx is not an error
+func (srv *EthServer) sendRawTx(ctx context.Context, tx *types.Transaction) error {
+	rawTx, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	call := rpc.SendRawTransaction(rawTx)
+	if srv.Config.BroadcastAll {
+		err = srv.C.CallEveryUpstream(ctx, call.Raw())
+	} else {
+		_, err = call.Call(ctx, srv.C)
+	}
+	if err == nil {
+		return nil
+	}
+
+	switch rpcerrors.ClassifyUpstreamError(err) {
+	case rpcerrors.ReasonAlreadyKnown:
+		srv.Log.Debug("tx already known", "hash", tx.Hash(), "err", err)
+		return nil
+	case rpcerrors.ReasonNonceTooLow:
+		// nonce is used either by this very tx sent before or by other one
+		if known, kErr := srv.isTxKnown(ctx, tx.Hash()); kErr == nil && known {
+			srv.Log.Debug("tx already included", "hash", tx.Hash())
+			return nil
+		}
+		// reservations are behind the chain, e.g. sender used other wallet
+		if from, sErr := types.Sender(srv.Signer(), tx); sErr == nil {
+			srv.ResyncNonce(ctx, from)
+		}
+	}
+	return err
+}
+
+// check if node reports that the same transaction was already accepted
+func IsKnownTxError(err error) bool {
+	return rpcerrors.ClassifyUpstreamError(err) == rpcerrors.ReasonAlreadyKnown
+}
+
+// check if node knows tx with the hash, either pending or included
+func (srv *EthServer) isTxKnown(ctx context.Context, hash common.Hash) (bool, error) {
+	tx, err := rpc.GetTransactionByHash(hash).Call(ctx, srv.C)
+	if err != nil {
+		return false, err
+	}
+	return tx != nil, nil
+}
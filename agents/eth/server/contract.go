@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/agents/eth/rpc"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"github.com/ubtr/ubt/go/api/proto/services"
+	"google.golang.org/grpc/codes"
+)
+
+var _ agent.ContractIntentProvider = (*EthServer)(nil)
+
+func (srv *EthServer) CreateContractCall(ctx context.Context, req *agent.ContractCallRequest) (*services.TransactionIntent, error) {
+	from, err := srv.AddressFromString(req.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := srv.AddressFromString(req.To)
+	if err != nil {
+		return nil, err
+	}
+	data, err := srv.ContractCallData(req)
+	if err != nil {
+		return nil, err
+	}
+	return srv.createContractIntent(ctx, from, &to, req.Value, data, req.GasLimit)
+}
+
+func (srv *EthServer) CreateContractDeploy(ctx context.Context, req *agent.ContractDeployRequest) (*services.TransactionIntent, error) {
+	if len(req.Bytecode) == 0 {
+		return nil, rpcerrors.ArgError("bytecode", errors.New("empty bytecode"))
+	}
+	from, err := srv.AddressFromString(req.From)
+	if err != nil {
+		return nil, err
+	}
+	data := append(append([]byte{}, req.Bytecode...), req.ConstructorArgs...)
+	return srv.createContractIntent(ctx, from, nil, req.Value, data, req.GasLimit)
+}
+
+// estimate gas unless overridden and build intent; to is nil for deployment
+func (srv *EthServer) createContractIntent(ctx context.Context, from common.Address, to *common.Address, value *big.Int, data []byte, gasLimit uint64) (*services.TransactionIntent, error) {
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	if value.Sign() < 0 {
+		return nil, rpcerrors.ErrInvalidAmount
+	}
+
+	feeOpts, err := agent.FeeOptionsFromContext(ctx, &srv.Config)
+	if err != nil {
+		return nil, err
+	}
+	fees, err := srv.SuggestFees(ctx, feeOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	if gasLimit == 0 {
+		gasLimit, err = rpc.AdoptClient(srv.C).EstimateGas(ctx, ethereum.CallMsg{
+			From:  from,
+			To:    to,
+			Value: value,
+			Data:  data,
+		})
+		if err != nil {
+			if reason, ok := RevertReasonFromError(err); ok {
+				return nil, rpcerrors.RevertError("contract call reverts", reason)
+			}
+			return nil, rpcerrors.UpstreamError(err, codes.Internal, "failed to estimate gas")
+		}
+	}
+	srv.Log.Debug("contract intent", "to", to, "value", value, "gas", gasLimit, "dataLen", len(data))
+
+	return srv.newIntent(ctx, fees, feeOpts, from, to, value, gasLimit, data)
+}
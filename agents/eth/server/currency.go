@@ -2,8 +2,12 @@ package server
 
 import (
 	"context"
+	"fmt"
+	"math/big"
+	"strings"
 
 	"github.com/eko/gocache/lib/v4/store"
+	"github.com/ubtr/ubt-go/agents/eth/contracts/erc1155"
 	"github.com/ubtr/ubt-go/agents/eth/contracts/erc20"
 	"github.com/ubtr/ubt-go/agents/eth/rpc"
 	"github.com/ubtr/ubt-go/blockchain"
@@ -12,7 +16,6 @@ import (
 	"github.com/ubtr/ubt/go/api/proto"
 	"github.com/ubtr/ubt/go/api/proto/services"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 func (srv *EthServer) GetCurrency(ctx context.Context, req *services.GetCurrencyRequest) (*proto.Currency, error) {
@@ -32,45 +35,95 @@ func (srv *EthServer) GetCurrency(ctx context.Context, req *services.GetCurrency
 			Symbol:   srv.Config.ChainType,
 			Decimals: uint32(srv.Chain.Decimals),
 		}, nil
-	} else if currencyId.Token == "" {
-		cached, err := srv.CurrencyCache.Get(ctx, req.Id)
-		if err == nil {
-			srv.Log.Debug("Currency cache hit", "currencyId", req.Id)
-			return cached, nil
-		} else {
-			srv.Log.Debug("Currency cache miss", "currencyId", req.Id)
-		}
+	}
+
+	cached, err := srv.CurrencyCache.Get(ctx, req.Id)
+	if err == nil {
+		srv.Log.Debug("Currency cache hit", "currencyId", req.Id)
+		return cached, nil
+	}
+	srv.Log.Debug("Currency cache miss", "currencyId", req.Id)
+	if registered := srv.registeredCurrency(ctx, req.Id, currencyId); registered != nil {
+		srv.CurrencyCache.Set(ctx, req.Id, registered, store.WithCost(1))
+		return registered, nil
+	}
+
+	if currencyId.Token == "" {
 		// erc20 token
 		// retreive token info
 		addr, err := srv.AddressFromString(currencyId.Address)
 		if err != nil {
 			return nil, err
 		}
-		tokenInst, err := erc20.NewErc20(addr, rpc.AdoptClient(srv.C))
+		meta, err := srv.FetchTokenMetadata(ctx, addr)
 		if err != nil {
-			srv.Log.Error("Failed to create token instance", "err", err)
-			return nil, rpcerrors.ErrInvalidCurrency
+			srv.Log.Error("Failed to get token metadata", "currencyId", req.Id, "err", err)
+			return nil, err
+		}
+		var ret = &proto.Currency{
+			Id:       req.Id,
+			Symbol:   meta.Symbol,
+			Decimals: uint32(meta.Decimals),
+		}
+		if meta.Name != "" {
+			ret.Metadata = &proto.CurrencyMetadata{Name: meta.Name}
 		}
-		symbol, err := tokenInst.Symbol(nil)
+		srv.CurrencyCache.Set(ctx, req.Id, ret, store.WithCost(1))
+		srv.registerCurrency(ctx, currencyId, ret)
+		return ret, nil
+	} else {
+		// erc-1155 or erc-721 token
+		addr, err := srv.AddressFromString(currencyId.Address)
 		if err != nil {
-			srv.Log.Error("Failed to get token symbol", "err", err)
+			return nil, err
+		}
+		tokenId, ok := new(big.Int).SetString(currencyId.Token, 10)
+		if !ok {
 			return nil, rpcerrors.ErrInvalidCurrency
 		}
-		decimals, err := tokenInst.Decimals(nil)
+		isErc721, err := srv.IsErc721(ctx, addr)
 		if err != nil {
-			srv.Log.Error("Failed to get token decimals", "err", err)
-			return nil, rpcerrors.ErrInvalidCurrency
+			return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to detect token standard")
+		}
+		var uri string
+		if isErc721 {
+			uri, err = srv.Erc721TokenUri(ctx, addr, tokenId)
+			if err != nil {
+				srv.Log.Error("Failed to get token uri", "err", err)
+				return nil, rpcerrors.ErrInvalidCurrency
+			}
+		} else {
+			tokenInst, err := erc1155.NewErc1155(addr, rpc.AdoptClient(srv.C))
+			if err != nil {
+				srv.Log.Error("Failed to create token instance", "err", err)
+				return nil, rpcerrors.ErrInvalidCurrency
+			}
+			uri, err = tokenInst.Uri(nil, tokenId)
+			if err != nil {
+				srv.Log.Error("Failed to get token uri", "err", err)
+				return nil, rpcerrors.ErrInvalidCurrency
+			}
+			uri = Erc1155TokenUri(uri, tokenId)
 		}
 		var ret = &proto.Currency{
 			Id:       req.Id,
-			Symbol:   symbol,
-			Decimals: uint32(decimals),
+			Decimals: 0,
+			Metadata: &proto.CurrencyMetadata{IconUrl: uri},
+		}
+		// symbol is not part of erc-1155 but many contracts provide it, erc-721 metadata extension has it
+		if symbolInst, err := erc20.NewErc20(addr, rpc.AdoptClient(srv.C)); err == nil {
+			if symbol, err := symbolInst.Symbol(nil); err == nil {
+				ret.Symbol = symbol
+			}
 		}
 		srv.CurrencyCache.Set(ctx, req.Id, ret, store.WithCost(1))
+		srv.registerCurrency(ctx, currencyId, ret)
 		return ret, nil
-	} else {
-		// erc-1155 token
-		return nil, status.Errorf(codes.Unimplemented, "ERC-1155 not supported yet")
 	}
 
 }
+
+// substitute {id} placeholder of erc-1155 uri with lowercase hex token id padded to 64 chars
+func Erc1155TokenUri(uri string, tokenId *big.Int) string {
+	return strings.ReplaceAll(uri, "{id}", fmt.Sprintf("%064x", tokenId))
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/agents/eth/rpc"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"github.com/ubtr/ubt/go/api/proto/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// multiplier applied to the next block base fee to get max fee per gas,
+// allows tx to stay includable while base fee grows for several blocks
+const baseFeeMultiplier = 2
+
+// fee parameters of a transaction; GasPrice is set for legacy transactions,
+// GasTipCap/GasFeeCap for dynamic fee (EIP-1559) ones
+type TxFees struct {
+	GasPrice  *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+	BaseFee   *big.Int // expected base fee of the next block
+}
+
+func (f *TxFees) IsDynamic() bool {
+	return f.GasFeeCap != nil
+}
+
+// effective price per gas expected to be paid if tx is included into the next block
+func (f *TxFees) ExpectedGasPrice() *big.Int {
+	if !f.IsDynamic() {
+		return f.GasPrice
+	}
+	price := new(big.Int).Add(f.BaseFee, f.GasTipCap)
+	if price.Cmp(f.GasFeeCap) > 0 {
+		return new(big.Int).Set(f.GasFeeCap)
+	}
+	return price
+}
+
+// maximum price per gas tx could pay
+func (f *TxFees) MaxGasPrice() *big.Int {
+	if !f.IsDynamic() {
+		return f.GasPrice
+	}
+	return f.GasFeeCap
+}
+
+func (f *TxFees) ExpectedFee(gas uint64) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(gas), f.ExpectedGasPrice())
+}
+
+func (f *TxFees) MaxFee(gas uint64) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(gas), f.MaxGasPrice())
+}
+
+// build unsigned transaction using fee parameters
+func (f *TxFees) NewTx(chainId *big.Int, nonce uint64, to *common.Address, value *big.Int, gas uint64, data []byte) *types.Transaction {
+	if !f.IsDynamic() {
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			To:       to,
+			Value:    value,
+			Gas:      gas,
+			GasPrice: f.GasPrice,
+			Data:     data,
+		})
+	}
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainId,
+		Nonce:     nonce,
+		To:        to,
+		Value:     value,
+		Gas:       gas,
+		GasTipCap: f.GasTipCap,
+		GasFeeCap: f.GasFeeCap,
+		Data:      data,
+	})
+}
+
+// number of recent blocks to sample priority fees from
+const feeHistoryBlocks = 10
+
+// reward percentiles for slow, normal and fast tiers
+var feeSpeedPercentiles = map[agent.FeeSpeed]int{
+	agent.FeeSpeedSlow:   0,
+	agent.FeeSpeedNormal: 1,
+	agent.FeeSpeedFast:   2,
+}
+var feeHistoryPercentiles = []float64{10, 50, 90}
+
+// legacy gas price multipliers in percents for slow, normal and fast tiers
+var legacySpeedMultipliers = map[agent.FeeSpeed]int64{
+	agent.FeeSpeedSlow:   90,
+	agent.FeeSpeedNormal: 100,
+	agent.FeeSpeedFast:   125,
+}
+
+// calculate dynamic fees for every speed tier from fee history;
+// tip of the tier is an average of its reward percentile over sampled blocks, fallbackTip is used if there are no rewards
+func FeeTiersFromHistory(history *ethereum.FeeHistory, fallbackTip *big.Int) map[agent.FeeSpeed]*TxFees {
+	if len(history.BaseFee) == 0 || history.BaseFee[len(history.BaseFee)-1] == nil || history.BaseFee[len(history.BaseFee)-1].Sign() <= 0 {
+		return nil
+	}
+	// last element is a base fee of the next block
+	baseFee := history.BaseFee[len(history.BaseFee)-1]
+
+	tiers := make(map[agent.FeeSpeed]*TxFees, len(feeSpeedPercentiles))
+	for speed, idx := range feeSpeedPercentiles {
+		tip := big.NewInt(0)
+		count := int64(0)
+		for _, blockRewards := range history.Reward {
+			if idx < len(blockRewards) && blockRewards[idx] != nil {
+				tip.Add(tip, blockRewards[idx])
+				count++
+			}
+		}
+		if count > 0 {
+			tip.Div(tip, big.NewInt(count))
+		} else {
+			tip.Set(fallbackTip)
+		}
+		feeCap := new(big.Int).Mul(baseFee, big.NewInt(baseFeeMultiplier))
+		feeCap.Add(feeCap, tip)
+		tiers[speed] = &TxFees{GasTipCap: tip, GasFeeCap: feeCap, BaseFee: baseFee}
+	}
+	return tiers
+}
+
+// get fees for a new transaction; dynamic fees are used unless chain is configured
+// for legacy transactions or node does not report base fee
+func (srv *EthServer) SuggestFees(ctx context.Context, opts agent.FeeOptions) (*TxFees, error) {
+	client := rpc.AdoptClient(srv.C)
+	if !srv.Config.LegacyTx {
+		history, err := client.FeeHistory(ctx, feeHistoryBlocks, nil, feeHistoryPercentiles)
+		if err != nil {
+			return nil, rpcerrors.UpstreamError(err, codes.Internal, "failed to get fee history")
+		}
+		if len(history.BaseFee) > 0 {
+			tip, err := client.SuggestGasTipCap(ctx)
+			if err != nil {
+				return nil, rpcerrors.UpstreamError(err, codes.Internal, "failed to get gas tip cap")
+			}
+			tiers := FeeTiersFromHistory(history, tip)
+			if tiers != nil {
+				fees := tiers[opts.Speed]
+				if opts.MaxFee != nil {
+					if opts.MaxFee.Cmp(fees.BaseFee) < 0 {
+						// node rejects such tx or it never gets included
+						return nil, rpcerrors.ArgError(agent.MetadataMaxFee, fmt.Errorf("max fee %s is below base fee %s of the next block", opts.MaxFee, fees.BaseFee))
+					}
+					fees.GasFeeCap = new(big.Int).Set(opts.MaxFee)
+					if fees.GasTipCap.Cmp(fees.GasFeeCap) > 0 {
+						fees.GasTipCap = new(big.Int).Set(fees.GasFeeCap)
+					}
+				}
+				return fees, nil
+			}
+		}
+		srv.Log.Debug("no base fee reported, falling back to legacy tx")
+	}
+
+	if opts.MaxFee != nil {
+		return &TxFees{GasPrice: new(big.Int).Set(opts.MaxFee)}, nil
+	}
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Internal, "failed to get gas price")
+	}
+	gasPrice.Mul(gasPrice, big.NewInt(legacySpeedMultipliers[opts.Speed]))
+	gasPrice.Div(gasPrice, big.NewInt(100))
+	return &TxFees{GasPrice: gasPrice}, nil
+}
+
+// signer used both to calculate payload to sign and to apply signature;
+// supports legacy (EIP-155) and dynamic fee transactions
+func (srv *EthServer) Signer() types.Signer {
+	return types.NewLondonSigner(srv.ChainId)
+}
+
+// maximum fee intent could pay, as opposed to intent EstimatedFee which is an expected fee
+func IntentMaxFee(intent *services.TransactionIntent) (*big.Int, error) {
+	tx := &types.Transaction{}
+	if err := tx.UnmarshalBinary(intent.RawData); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to unmarshal raw tx: %v", err)
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasFeeCap()), nil
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDynamicFees(t *testing.T) {
+	fees := &TxFees{GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(22), BaseFee: big.NewInt(10)}
+
+	if fees.ExpectedFee(21000).Cmp(big.NewInt(21000*12)) != 0 {
+		t.Errorf("expected fee %d, got %s", 21000*12, fees.ExpectedFee(21000))
+	}
+	if fees.MaxFee(21000).Cmp(big.NewInt(21000*22)) != 0 {
+		t.Errorf("expected max fee %d, got %s", 21000*22, fees.MaxFee(21000))
+	}
+
+	// base fee grew above the cap
+	fees.BaseFee = big.NewInt(30)
+	if fees.ExpectedGasPrice().Cmp(fees.GasFeeCap) != 0 {
+		t.Errorf("expected gas price capped to %s, got %s", fees.GasFeeCap, fees.ExpectedGasPrice())
+	}
+
+	to := common.HexToAddress("0x1a642f0E3c3aF545E7AcBD38b07251B3990914F1")
+	tx := fees.NewTx(big.NewInt(1), 1, &to, big.NewInt(1), 21000, nil)
+	if tx.Type() != types.DynamicFeeTxType {
+		t.Errorf("expected dynamic fee tx, got type %d", tx.Type())
+	}
+}
+
+func TestLegacyFees(t *testing.T) {
+	fees := &TxFees{GasPrice: big.NewInt(5)}
+
+	if fees.ExpectedFee(21000).Cmp(fees.MaxFee(21000)) != 0 {
+		t.Errorf("expected legacy fee to equal max fee")
+	}
+
+	to := common.HexToAddress("0x1a642f0E3c3aF545E7AcBD38b07251B3990914F1")
+	tx := fees.NewTx(big.NewInt(1), 1, &to, big.NewInt(1), 21000, nil)
+	if tx.Type() != types.LegacyTxType {
+		t.Errorf("expected legacy tx, got type %d", tx.Type())
+	}
+}
+
+func TestSuggestFeesRateLimited(t *testing.T) {
+	srv := newTestServer(t, map[string]any{
+		"eth_feeHistory": &testRpcError{code: -32005, msg: "limit exceeded"},
+	})
+
+	_, err := srv.SuggestFees(context.Background(), agent.FeeOptions{})
+	if reason := rpcerrors.ReasonFromError(err); reason != rpcerrors.ReasonRateLimited {
+		t.Errorf("expected %s reason, got %q (%v)", rpcerrors.ReasonRateLimited, reason, err)
+	}
+}
+
+func TestSuggestFeesMaxFee(t *testing.T) {
+	srv := newTestServer(t, map[string]any{
+		"eth_feeHistory": map[string]any{
+			"oldestBlock": "0x1", "baseFeePerGas": []string{"0x64", "0x64"}, "gasUsedRatio": []float64{0.5},
+			"reward": [][]string{{"0x1", "0x5", "0xa"}},
+		},
+		"eth_maxPriorityFeePerGas": "0x5",
+	})
+
+	fees, err := srv.SuggestFees(context.Background(), agent.FeeOptions{MaxFee: big.NewInt(102)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.GasFeeCap.Int64() != 102 || fees.GasTipCap.Int64() != 5 {
+		t.Errorf("expected fee cap 102 and tip 5, got %s %s", fees.GasFeeCap, fees.GasTipCap)
+	}
+
+	// max fee below base fee never gets included
+	_, err = srv.SuggestFees(context.Background(), agent.FeeOptions{MaxFee: big.NewInt(99)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected invalid argument, got %v", err)
+	}
+}
+
+func TestFeeTiersFromHistory(t *testing.T) {
+	history := &ethereum.FeeHistory{
+		Reward: [][]*big.Int{
+			{big.NewInt(1), big.NewInt(2), big.NewInt(5)},
+			{big.NewInt(3), big.NewInt(4), big.NewInt(7)},
+		},
+		BaseFee: []*big.Int{big.NewInt(8), big.NewInt(9), big.NewInt(10)},
+	}
+	tiers := FeeTiersFromHistory(history, big.NewInt(100))
+
+	expectedTips := map[agent.FeeSpeed]int64{agent.FeeSpeedSlow: 2, agent.FeeSpeedNormal: 3, agent.FeeSpeedFast: 6}
+	for speed, tip := range expectedTips {
+		fees := tiers[speed]
+		if fees.GasTipCap.Cmp(big.NewInt(tip)) != 0 {
+			t.Errorf("%s: expected tip %d, got %s", speed, tip, fees.GasTipCap)
+		}
+		if fees.BaseFee.Cmp(big.NewInt(10)) != 0 {
+			t.Errorf("%s: expected next block base fee 10, got %s", speed, fees.BaseFee)
+		}
+		if fees.GasFeeCap.Cmp(big.NewInt(20+tip)) != 0 {
+			t.Errorf("%s: expected fee cap %d, got %s", speed, 20+tip, fees.GasFeeCap)
+		}
+	}
+
+	// no rewards reported
+	history.Reward = nil
+	tiers = FeeTiersFromHistory(history, big.NewInt(100))
+	if tiers[agent.FeeSpeedFast].GasTipCap.Cmp(big.NewInt(100)) != 0 {
+		t.Errorf("expected fallback tip, got %s", tiers[agent.FeeSpeedFast].GasTipCap)
+	}
+
+	// pre-london chain
+	history.BaseFee = nil
+	if FeeTiersFromHistory(history, big.NewInt(100)) != nil {
+		t.Errorf("expected no tiers without base fee")
+	}
+}
+
+func TestReplacementFees(t *testing.T) {
+	to := common.HexToAddress("0x1a642f0E3c3aF545E7AcBD38b07251B3990914F1")
+	original := (&TxFees{GasTipCap: big.NewInt(100), GasFeeCap: big.NewInt(1000)}).NewTx(big.NewInt(1), 1, &to, big.NewInt(1), 21000, nil)
+
+	// suggested fees are lower than original, bump is applied
+	fees := ReplacementFees(original, &TxFees{GasTipCap: big.NewInt(50), GasFeeCap: big.NewInt(500), BaseFee: big.NewInt(200)})
+	if fees.GasTipCap.Cmp(big.NewInt(110)) != 0 || fees.GasFeeCap.Cmp(big.NewInt(1100)) != 0 {
+		t.Errorf("expected bumped fees 110/1100, got %s/%s", fees.GasTipCap, fees.GasFeeCap)
+	}
+
+	// suggested fees are higher
+	fees = ReplacementFees(original, &TxFees{GasTipCap: big.NewInt(200), GasFeeCap: big.NewInt(2000), BaseFee: big.NewInt(900)})
+	if fees.GasTipCap.Cmp(big.NewInt(200)) != 0 || fees.GasFeeCap.Cmp(big.NewInt(2000)) != 0 {
+		t.Errorf("expected suggested fees 200/2000, got %s/%s", fees.GasTipCap, fees.GasFeeCap)
+	}
+
+	legacy := (&TxFees{GasPrice: big.NewInt(15)}).NewTx(big.NewInt(1), 1, &to, big.NewInt(1), 21000, nil)
+	fees = ReplacementFees(legacy, &TxFees{GasPrice: big.NewInt(10)})
+	if fees.GasPrice.Cmp(big.NewInt(17)) != 0 {
+		t.Errorf("expected rounded up bumped gas price 17, got %s", fees.GasPrice)
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"github.com/ubtr/ubt/go/api/proto"
+	"github.com/ubtr/ubt/go/api/proto/services"
+)
+
+const wsReconnectInterval = 5 * time.Second
+
+// keep streaming blocks until client disconnects, waiting for new heads at the tip
+func (srv *EthServer) followBlocks(startNumber uint64, count uint64, finality proto.FinalityStatus, lastSeenId []byte, res services.UbtBlockService_ListBlocksServer) error {
+	next := startNumber
+	for {
+		// subscribe before fetching so head changes in between are not missed
+		updated := srv.Heads.Updated()
+		sent, lastId, err := srv.sendBlocks(next, count, finality, lastSeenId, res)
+		if err != nil && err != rpcerrors.ErrBlockOutOfRange {
+			return err
+		}
+		if sent > 0 {
+			next += sent
+			lastSeenId = lastId
+		}
+		if sent == count {
+			// catching up
+			continue
+		}
+		srv.Log.Debug("Waiting for new heads", "next", next)
+		select {
+		case <-res.Context().Done():
+			return nil
+		case <-updated:
+		}
+	}
+}
+
+// refresh heads on every new block announced by websocket upstream, reconnecting on failures
+func (srv *EthServer) subscribeNewHeads(ctx context.Context, wsUrl string) {
+	for {
+		err := srv.watchNewHeads(ctx, wsUrl)
+		if ctx.Err() != nil {
+			return
+		}
+		srv.Log.Warn("newHeads subscription failed", "err", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wsReconnectInterval):
+		}
+	}
+}
+
+func (srv *EthServer) watchNewHeads(ctx context.Context, wsUrl string) error {
+	c, err := gethrpc.DialContext(ctx, wsUrl)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	headers := make(chan json.RawMessage)
+	sub, err := c.EthSubscribe(ctx, headers, "newHeads")
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+	srv.Log.Info("Subscribed to new heads")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-sub.Err():
+			return err
+		case <-headers:
+			if _, err := srv.Heads.Refresh(ctx); err != nil {
+				srv.Log.Warn("failed to refresh chain heads", "err", err)
+			}
+		}
+	}
+}
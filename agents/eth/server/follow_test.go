@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt/go/api/proto"
+	"github.com/ubtr/ubt/go/api/proto/services"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// server side of ListBlocks stream passing sent blocks to channel
+type testBlockStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	blocks chan *proto.Block
+}
+
+func (s *testBlockStream) Context() context.Context { return s.ctx }
+
+func (s *testBlockStream) Send(block *proto.Block) error {
+	s.blocks <- block
+	return nil
+}
+
+func TestFollowBlocks(t *testing.T) {
+	chain := newTestChain(t, 5)
+	results := chain.Results()
+	results["eth_getLogs"] = []any{}
+	srv := newTestServer(t, results)
+	srv.Heads = NewHeadTracker(NewTagHeadsFetcher(srv.C, 1, 2, slog.Default()).Fetch, time.Hour, slog.Default())
+	if _, err := srv.Heads.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(metadata.NewIncomingContext(context.Background(), metadata.Pairs(agent.MetadataFollow, "true")))
+	defer cancel()
+	stream := &testBlockStream{ctx: ctx, blocks: make(chan *proto.Block)}
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.ListBlocks(&services.ListBlocksRequest{StartNumber: 3}, stream)
+	}()
+
+	receive := func(number uint64) {
+		t.Helper()
+		select {
+		case block := <-stream.blocks:
+			if block.Header.Number != number {
+				t.Fatalf("expected block %d, got %d", number, block.Header.Number)
+			}
+		case err := <-done:
+			t.Fatalf("stream stopped waiting for block %d: %v", number, err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for block %d", number)
+		}
+	}
+	receive(3)
+	receive(4)
+
+	// stream waits at the tip until the head advances
+	select {
+	case block := <-stream.blocks:
+		t.Fatalf("unexpected block %d before new head", block.Header.Number)
+	case <-time.After(100 * time.Millisecond):
+	}
+	chain.Extend()
+	if _, err := srv.Heads.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	receive(5)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected stream to stop without error, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not stop after cancellation")
+	}
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/ubtr/ubt-go/agents/eth/rpc"
+	"github.com/ubtr/ubt-go/commons/jsonrpc"
+	"github.com/ubtr/ubt-go/commons/jsonrpc/client"
+	"github.com/ubtr/ubt/go/api/proto"
+)
+
+const (
+	DefaultHeadPollInterval = 6 * time.Second
+	DefaultSafeDepth        = 32 // one epoch of ethereum slots
+	DefaultFinalityDepth    = 64 // two epochs of ethereum slots
+)
+
+// chain heads known to the agent
+type Heads struct {
+	Latest    uint64
+	Safe      uint64
+	Finalized uint64
+	UpdatedAt time.Time
+}
+
+// heads derived from latest block number by confirmation depth
+func HeadsFromDepth(latest uint64, safeDepth uint64, finalityDepth uint64) Heads {
+	below := func(depth uint64) uint64 {
+		if latest < depth {
+			return 0
+		}
+		return latest - depth
+	}
+	return Heads{Latest: latest, Safe: below(safeDepth), Finalized: below(finalityDepth)}
+}
+
+// classify block by number
+func (h *Heads) Status(number uint64) proto.FinalityStatus {
+	if number <= h.Finalized {
+		return proto.FinalityStatus_FINALITY_STATUS_FINALIZED
+	} else if number <= h.Safe {
+		return proto.FinalityStatus_FINALITY_STATUS_SAFE
+	}
+	return proto.FinalityStatus_FINALITY_STATUS_UNSAFE
+}
+
+// fetch current chain heads
+type HeadsFetcher func(ctx context.Context) (*Heads, error)
+
+// periodically refreshes chain heads with a chain specific fetcher
+type HeadTracker struct {
+	Fetch    HeadsFetcher
+	Interval time.Duration
+	Log      *slog.Logger
+
+	heads   atomic.Pointer[Heads]
+	mu      sync.Mutex
+	updated chan struct{}
+}
+
+func NewHeadTracker(fetch HeadsFetcher, interval time.Duration, log *slog.Logger) *HeadTracker {
+	if interval == 0 {
+		interval = DefaultHeadPollInterval
+	}
+	return &HeadTracker{Fetch: fetch, Interval: interval, Log: log}
+}
+
+// start polling until context is done
+func (t *HeadTracker) Start(ctx context.Context) *HeadTracker {
+	go func() {
+		ticker := time.NewTicker(t.Interval)
+		defer ticker.Stop()
+		for {
+			if _, err := t.Refresh(ctx); err != nil {
+				t.Log.Warn("failed to refresh chain heads", "err", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return t
+}
+
+// fetch current heads and remember them
+func (t *HeadTracker) Refresh(ctx context.Context) (*Heads, error) {
+	heads, err := t.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	heads.UpdatedAt = time.Now()
+	prev := t.heads.Swap(heads)
+	if prev == nil || prev.Latest != heads.Latest || prev.Safe != heads.Safe || prev.Finalized != heads.Finalized {
+		t.mu.Lock()
+		if t.updated != nil {
+			close(t.updated)
+			t.updated = nil
+		}
+		t.mu.Unlock()
+	}
+	return heads, nil
+}
+
+// channel closed on the next change of heads
+func (t *HeadTracker) Updated() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.updated == nil {
+		t.updated = make(chan struct{})
+	}
+	return t.updated
+}
+
+// get last known heads, fetching them if tracker has not refreshed yet or they are stale
+func (t *HeadTracker) Heads(ctx context.Context) (*Heads, error) {
+	heads := t.heads.Load()
+	if heads != nil && time.Since(heads.UpdatedAt) < 2*t.Interval {
+		return heads, nil
+	}
+	return t.Refresh(ctx)
+}
+
+// fetches `safe` and `finalized` block tags; on nodes without them falls back to confirmation depth
+type TagHeadsFetcher struct {
+	C             *client.BalancedClient
+	SafeDepth     uint64
+	FinalityDepth uint64
+	Log           *slog.Logger
+
+	tagsUnsupported atomic.Bool
+}
+
+func NewTagHeadsFetcher(c *client.BalancedClient, safeDepth uint64, finalityDepth uint64, log *slog.Logger) *TagHeadsFetcher {
+	if safeDepth == 0 {
+		safeDepth = DefaultSafeDepth
+	}
+	if finalityDepth == 0 {
+		finalityDepth = DefaultFinalityDepth
+	}
+	return &TagHeadsFetcher{C: c, SafeDepth: safeDepth, FinalityDepth: finalityDepth, Log: log}
+}
+
+func (f *TagHeadsFetcher) Fetch(ctx context.Context) (*Heads, error) {
+	var batch jsonrpc.RpcBatch
+	latestCall := rpc.GetBlockNumber()
+	latestCall.AddToBatch(&batch)
+	useTags := !f.tagsUnsupported.Load()
+	safeCall := rpc.GetBlockByNumber(big.NewInt(int64(gethrpc.SafeBlockNumber)), false)
+	finalizedCall := rpc.GetBlockByNumber(big.NewInt(int64(gethrpc.FinalizedBlockNumber)), false)
+	if useTags {
+		safeCall.AddToBatch(&batch)
+		finalizedCall.AddToBatch(&batch)
+	}
+	if err := batch.Call(ctx, f.C); err != nil {
+		return nil, err
+	}
+	if err := latestCall.ProcessRes(ctx); err != nil {
+		return nil, err
+	}
+
+	heads := HeadsFromDepth(*latestCall.Response, f.SafeDepth, f.FinalityDepth)
+	if useTags {
+		safeErr := safeCall.ProcessRes(ctx)
+		finalizedErr := finalizedCall.ProcessRes(ctx)
+		for _, err := range []error{safeErr, finalizedErr} {
+			if err != nil && !isBlockTagUnsupported(err) {
+				// timeouts and rate limits say nothing about tag support
+				return nil, err
+			}
+		}
+		if safeErr != nil || finalizedErr != nil {
+			f.Log.Info("node does not support safe and finalized block tags, using confirmation depth",
+				"safeDepth", f.SafeDepth, "finalityDepth", f.FinalityDepth, "safeErr", safeErr, "finalizedErr", finalizedErr)
+			f.tagsUnsupported.Store(true)
+		} else {
+			// null while node is syncing or chain has not finalized any block yet
+			heads.Safe, heads.Finalized = 0, 0
+			if safe := *safeCall.Response; safe != nil {
+				heads.Safe = safe.Header.Number.Uint64()
+			}
+			if finalized := *finalizedCall.Response; finalized != nil {
+				heads.Finalized = finalized.Header.Number.Uint64()
+			}
+		}
+	}
+	return &heads, nil
+}
+
+// json-rpc invalid params error, returned by nodes which can not parse block tag
+const rpcCodeInvalidParams = -32602
+
+// check if node rejected block tag itself rather than failed to serve the request
+func isBlockTagUnsupported(err error) bool {
+	if isMethodNotFound(err) {
+		return true
+	}
+	var rpcErr gethrpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == rpcCodeInvalidParams {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "block tag") || strings.Contains(msg, "unknown block")
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethtypes "github.com/ubtr/ubt-go/agents/eth/types"
+	"github.com/ubtr/ubt/go/api/proto"
+)
+
+func TestHeadsStatus(t *testing.T) {
+	heads := HeadsFromDepth(100, 10, 20)
+	if heads.Safe != 90 || heads.Finalized != 80 {
+		t.Fatalf("expected safe 90 finalized 80, got %d %d", heads.Safe, heads.Finalized)
+	}
+	for number, expected := range map[uint64]proto.FinalityStatus{
+		80:  proto.FinalityStatus_FINALITY_STATUS_FINALIZED,
+		81:  proto.FinalityStatus_FINALITY_STATUS_SAFE,
+		90:  proto.FinalityStatus_FINALITY_STATUS_SAFE,
+		91:  proto.FinalityStatus_FINALITY_STATUS_UNSAFE,
+		101: proto.FinalityStatus_FINALITY_STATUS_UNSAFE,
+	} {
+		if status := heads.Status(number); status != expected {
+			t.Errorf("block %d: expected %v, got %v", number, expected, status)
+		}
+	}
+
+	early := HeadsFromDepth(5, 10, 20)
+	if early.Safe != 0 || early.Finalized != 0 {
+		t.Errorf("expected zero heads below depth, got %d %d", early.Safe, early.Finalized)
+	}
+}
+
+func TestHeadTrackerUpdated(t *testing.T) {
+	latest := uint64(10)
+	tracker := NewHeadTracker(func(ctx context.Context) (*Heads, error) {
+		heads := HeadsFromDepth(latest, 1, 2)
+		return &heads, nil
+	}, time.Second, slog.Default())
+
+	if _, err := tracker.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	updated := tracker.Updated()
+	if _, err := tracker.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-updated:
+		t.Fatal("expected no notification without new heads")
+	default:
+	}
+
+	latest++
+	if _, err := tracker.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-updated:
+	default:
+		t.Fatal("expected notification on new head")
+	}
+}
+
+// safe/finalized heads are fetched without tx details, transactions come as hashes
+func TestDecodeBlockWithTxHashes(t *testing.T) {
+	header := &types.Header{Number: big.NewInt(42), Difficulty: big.NewInt(0)}
+	raw, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Fatal(err)
+	}
+	fields["transactions"] = []string{common.HexToHash("0x01").Hex(), common.HexToHash("0x02").Hex()}
+	if raw, err = json.Marshal(fields); err != nil {
+		t.Fatal(err)
+	}
+
+	var block ethtypes.HeaderWithBody
+	if err := json.Unmarshal(raw, &block); err != nil {
+		t.Fatalf("failed to decode block with tx hashes: %v", err)
+	}
+	if block.Header.Number.Uint64() != 42 {
+		t.Errorf("expected block 42, got %d", block.Header.Number)
+	}
+	if len(block.Body.Transactions) != 0 {
+		t.Errorf("expected no decoded transactions, got %d", len(block.Body.Transactions))
+	}
+}
+
+func TestTagHeadsFetcherTransientError(t *testing.T) {
+	// rate limited tag request does not disable tags
+	limited := NewTagHeadsFetcher(newTestUpstream(t, map[string]any{
+		"eth_blockNumber":      "0x64",
+		"eth_getBlockByNumber": &testRpcError{code: -32005, msg: "limit exceeded"},
+	}), 10, 20, slog.Default())
+	if _, err := limited.Fetch(context.Background()); err == nil {
+		t.Error("expected transient error to be returned")
+	}
+	if limited.tagsUnsupported.Load() {
+		t.Error("expected tags to stay enabled after transient error")
+	}
+
+	// method not found switches to depth
+	fetcher := NewTagHeadsFetcher(newTestUpstream(t, map[string]any{"eth_blockNumber": "0x64"}), 10, 20, slog.Default())
+	heads, err := fetcher.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if heads.Safe != 90 || heads.Finalized != 80 || !fetcher.tagsUnsupported.Load() {
+		t.Errorf("expected depth based heads, got %+v", heads)
+	}
+}
+
+// null safe/finalized block means no such head yet, e.g. node is syncing, tags stay in use
+func TestTagHeadsFetcherNoTagHeadYet(t *testing.T) {
+	var finalizing atomic.Bool
+	fetcher := NewTagHeadsFetcher(newTestUpstream(t, map[string]any{
+		"eth_blockNumber": "0x64",
+		"eth_getBlockByNumber": testRpcHandler(func(params []json.RawMessage) any {
+			if !finalizing.Load() {
+				return nil
+			}
+			number := map[string]int64{`"safe"`: 95, `"finalized"`: 85}[string(params[0])]
+			return &types.Header{Number: big.NewInt(number), Difficulty: big.NewInt(0)}
+		}),
+	}), 10, 20, slog.Default())
+
+	heads, err := fetcher.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if heads.Latest != 100 || heads.Safe != 0 || heads.Finalized != 0 || fetcher.tagsUnsupported.Load() {
+		t.Errorf("expected no safe and finalized heads with tags enabled, got %+v", heads)
+	}
+
+	finalizing.Store(true)
+	if heads, err = fetcher.Fetch(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if heads.Safe != 95 || heads.Finalized != 85 {
+		t.Errorf("expected tag based heads, got %+v", heads)
+	}
+}
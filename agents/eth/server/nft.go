@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ubtr/ubt-go/agents/eth/rpc"
+)
+
+// erc-165 interface id of erc-721
+var Erc721InterfaceId = [4]byte{0x80, 0xac, 0x58, 0xcd}
+
+// minimal erc-721 abi used to build transfers and read token metadata
+const erc721AbiJson = `[
+	{"type":"function","name":"safeTransferFrom","stateMutability":"nonpayable","inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"tokenURI","stateMutability":"view","inputs":[{"name":"tokenId","type":"uint256"}],"outputs":[{"name":"","type":"string"}]}
+]`
+
+var erc721Abi = sync.OnceValues(func() (*abi.ABI, error) {
+	parsed, err := abi.JSON(strings.NewReader(erc721AbiJson))
+	return &parsed, err
+})
+
+// check with erc-165 whether contract is erc-721; contracts reverting or returning no data are treated as not erc-721,
+// upstream failures are returned
+func (srv *EthServer) IsErc721(ctx context.Context, contract common.Address) (bool, error) {
+	parsed, err := erc1155Abi()
+	if err != nil {
+		return false, err
+	}
+	input, err := parsed.Pack("supportsInterface", Erc721InterfaceId)
+	if err != nil {
+		return false, err
+	}
+	output, err := rpc.Call(ethereum.CallMsg{To: &contract, Data: input}, nil).Call(ctx, srv.C)
+	if err != nil {
+		if _, reverted := RevertReasonFromError(err); reverted {
+			srv.Log.Debug("supportsInterface reverted", "contract", contract, "err", err)
+			return false, nil
+		}
+		return false, err
+	}
+	if len(output) == 0 {
+		// no code or no erc-165 fallback
+		return false, nil
+	}
+	out, err := parsed.Unpack("supportsInterface", output)
+	if err != nil {
+		return false, nil
+	}
+	return *abi.ConvertType(out[0], new(bool)).(*bool), nil
+}
+
+// get erc-721 token uri
+func (srv *EthServer) Erc721TokenUri(ctx context.Context, contract common.Address, tokenId *big.Int) (string, error) {
+	parsed, err := erc721Abi()
+	if err != nil {
+		return "", err
+	}
+	var out []interface{}
+	err = bind.NewBoundContract(contract, *parsed, rpc.AdoptClient(srv.C), nil, nil).Call(&bind.CallOpts{Context: ctx}, &out, "tokenURI", tokenId)
+	if err != nil {
+		return "", err
+	}
+	return *abi.ConvertType(out[0], new(string)).(*string), nil
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestIsErc721(t *testing.T) {
+	contract := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	for _, c := range []struct {
+		name     string
+		result   any
+		expected bool
+		err      bool
+	}{
+		{"erc721", "0x0000000000000000000000000000000000000000000000000000000000000001", true, false},
+		{"erc1155", "0x0000000000000000000000000000000000000000000000000000000000000000", false, false},
+		{"no erc165", "0x", false, false},
+		{"reverted", &testRpcError{code: 3, msg: "execution reverted"}, false, false},
+		{"rate limited", &testRpcError{code: -32005, msg: "limit exceeded"}, false, true},
+		{"node error", &testRpcError{code: -32000, msg: "header not found"}, false, true},
+	} {
+		srv := newTestServer(t, map[string]any{"eth_call": c.result})
+		isErc721, err := srv.IsErc721(context.Background(), contract)
+		if (err != nil) != c.err {
+			t.Errorf("%s: unexpected error %v", c.name, err)
+		}
+		if isErc721 != c.expected {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, isErc721)
+		}
+	}
+}
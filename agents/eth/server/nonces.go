@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ubtr/ubt-go/agents/eth/rpc"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"github.com/ubtr/ubt/go/api/proto/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// reserve nonce for a new intent; intents created for the same sender before broadcast get sequential nonces
+func (srv *EthServer) ReserveNonce(ctx context.Context, from common.Address) (uint64, error) {
+	chainNonce, err := rpc.AdoptClient(srv.C).PendingNonceAt(ctx, from)
+	if err != nil {
+		return 0, rpcerrors.UpstreamError(err, codes.Internal, "failed to get nonce")
+	}
+	nonce, err := srv.Nonces.Reserve(ctx, from.Hex(), chainNonce)
+	if err != nil {
+		return 0, status.Errorf(codes.Internal, "failed to reserve nonce: %v", err)
+	}
+	return nonce, nil
+}
+
+func (srv *EthServer) ReleaseNonce(ctx context.Context, from common.Address, nonce uint64) {
+	if err := srv.Nonces.Release(ctx, from.Hex(), nonce); err != nil {
+		srv.Log.Warn("failed to release nonce", "from", from, "nonce", nonce, "err", err)
+	}
+}
+
+// release nonce of the intent which will not be sent, so the next intent from the sender fills the gap
+func (srv *EthServer) AbandonIntent(ctx context.Context, from string, intent *services.TransactionIntent) error {
+	fromAddress, err := srv.AddressFromString(from)
+	if err != nil {
+		return err
+	}
+	tx := &types.Transaction{}
+	if err := tx.UnmarshalBinary(intent.RawData); err != nil {
+		return status.Errorf(codes.InvalidArgument, "failed to unmarshal raw tx: %v", err)
+	}
+	if err := srv.Nonces.Release(ctx, fromAddress.Hex(), tx.Nonce()); err != nil {
+		return status.Errorf(codes.Internal, "failed to release nonce: %v", err)
+	}
+	return nil
+}
+
+// drop nonce reservations of the sender and continue from its pending nonce on chain
+func (srv *EthServer) ResyncNonce(ctx context.Context, from common.Address) error {
+	chainNonce, err := rpc.AdoptClient(srv.C).PendingNonceAt(ctx, from)
+	if err != nil {
+		return rpcerrors.UpstreamError(err, codes.Internal, "failed to get nonce")
+	}
+	if err := srv.Nonces.Resync(ctx, from.Hex(), chainNonce); err != nil {
+		return status.Errorf(codes.Internal, "failed to resync nonce: %v", err)
+	}
+	return nil
+}
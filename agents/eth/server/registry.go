@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/agents/eth/registry"
+	"github.com/ubtr/ubt-go/blockchain"
+	"github.com/ubtr/ubt/go/api/proto"
+)
+
+// currency id with address in canonical chain format, so registry lookups do not depend on address case
+func (srv *EthServer) CanonicalCurrencyId(currencyId blockchain.UChainCurrencyId) (string, error) {
+	if currencyId.IsNative() {
+		return currencyId.String(), nil
+	}
+	addr, err := srv.AddressFromString(currencyId.Address)
+	if err != nil {
+		return "", err
+	}
+	canonical := blockchain.UChainCurrencyId{Address: srv.AddressToString(&addr), Token: currencyId.Token}
+	return canonical.String(), nil
+}
+
+func (srv *EthServer) registryChain() string {
+	return srv.Config.ChainType + ":" + srv.Config.ChainNetwork
+}
+
+// currency from persistent registry, nil if registry is disabled or currency is not registered
+func (srv *EthServer) registeredCurrency(ctx context.Context, reqId string, currencyId blockchain.UChainCurrencyId) *proto.Currency {
+	if srv.Currencies == nil {
+		return nil
+	}
+	id, err := srv.CanonicalCurrencyId(currencyId)
+	if err != nil {
+		return nil
+	}
+	record, err := srv.Currencies.Get(ctx, srv.registryChain(), id)
+	if err != nil {
+		srv.Log.Warn("failed to read currency registry", "currencyId", id, "err", err)
+		return nil
+	}
+	if record == nil {
+		return nil
+	}
+	ret := record.ToProto()
+	ret.Id = reqId
+	return ret
+}
+
+// store currency fetched from chain; records from token lists and overrides are kept
+func (srv *EthServer) registerCurrency(ctx context.Context, currencyId blockchain.UChainCurrencyId, currency *proto.Currency) {
+	if srv.Currencies == nil {
+		return
+	}
+	id, err := srv.CanonicalCurrencyId(currencyId)
+	if err != nil {
+		return
+	}
+	record := registry.RecordFromProto(srv.registryChain(), currency, registry.SourceChain)
+	record.CurrencyId = id
+	if err := srv.Currencies.Put(ctx, record); err != nil {
+		srv.Log.Warn("failed to store currency", "currencyId", id, "err", err)
+	}
+}
+
+// token lists and overrides are stored in the registry, they would be lost without a database
+func checkCurrencyRegistryConfig(config *agent.ChainConfig) error {
+	if config.CurrencyDb == "" && (len(config.TokenLists) > 0 || len(config.Currencies) > 0) {
+		return errors.New("currencyDb is required to use tokenLists and currencies")
+	}
+	return nil
+}
+
+// open registry configured for the chain, import token lists and apply overrides
+func (srv *EthServer) initCurrencyRegistry(ctx context.Context) error {
+	reg, err := registry.OpenRegistry(srv.Config.CurrencyDb)
+	if err != nil {
+		return err
+	}
+	chain := srv.registryChain()
+	currencyId := func(address string) (string, error) {
+		id, err := blockchain.UChainCurrencyIdromString(address)
+		if err != nil {
+			return "", err
+		}
+		return srv.CanonicalCurrencyId(id)
+	}
+
+	for _, path := range srv.Config.TokenLists {
+		count, err := reg.ImportTokenListFile(ctx, chain, srv.ChainId.Uint64(), path, currencyId)
+		if err != nil {
+			return err
+		}
+		srv.Log.Info("Imported token list", "path", path, "tokens", count)
+	}
+
+	var overrides []*registry.CurrencyRecord
+	for _, o := range srv.Config.Currencies {
+		id, err := currencyId(o.Id)
+		if err != nil {
+			return fmt.Errorf("currency override '%s': %w", o.Id, err)
+		}
+		overrides = append(overrides, &registry.CurrencyRecord{
+			Chain:      chain,
+			CurrencyId: id,
+			Symbol:     o.Symbol,
+			Name:       o.Name,
+			Decimals:   o.Decimals,
+			IconUrl:    o.IconUrl,
+			Source:     registry.SourceOverride,
+		})
+	}
+	if err := reg.Put(ctx, overrides...); err != nil {
+		return err
+	}
+
+	srv.Currencies = reg
+	return nil
+}
@@ -0,0 +1,19 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/ubtr/ubt-go/agent"
+)
+
+func TestCheckCurrencyRegistryConfig(t *testing.T) {
+	if err := checkCurrencyRegistryConfig(&agent.ChainConfig{TokenLists: []string{"tokens.json"}}); err == nil {
+		t.Error("expected error for token lists without currency db")
+	}
+	if err := checkCurrencyRegistryConfig(&agent.ChainConfig{Currencies: []agent.CurrencyOverride{{Id: "0x01"}}}); err == nil {
+		t.Error("expected error for overrides without currency db")
+	}
+	if err := checkCurrencyRegistryConfig(&agent.ChainConfig{CurrencyDb: "currencies.db", TokenLists: []string{"tokens.json"}}); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ubtr/ubt-go/agents/eth/rpc"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// how far back orphaned blocks are followed looking for the common ancestor
+const maxReorgDepth = 128
+
+// check that block following the last seen one continues the same chain; returns reorg error with orphaned blocks otherwise
+func (srv *EthServer) checkContinuity(ctx context.Context, lastSeenId []byte, parentHash common.Hash) error {
+	if bytes.Equal(lastSeenId, parentHash.Bytes()) {
+		return nil
+	}
+	if len(lastSeenId) != common.HashLength {
+		return rpcerrors.ArgError("lastBlockId", fmt.Errorf("invalid block id length %d", len(lastSeenId)))
+	}
+	info, err := srv.findOrphaned(ctx, common.BytesToHash(lastSeenId))
+	if err != nil {
+		return err
+	}
+	if len(info.Orphaned) == 0 {
+		// last seen block is canonical but it is not the parent of the start block
+		return rpcerrors.ArgError("lastBlockId", fmt.Errorf("block %x is not the parent of start block", lastSeenId))
+	}
+	srv.Log.Warn("Chain reorganization detected", "lastSeen", common.BytesToHash(lastSeenId), "orphaned", len(info.Orphaned), "ancestor", info.AncestorNumber)
+	return rpcerrors.ReorgError(*info)
+}
+
+// follow parents of the block until a canonical one is found
+func (srv *EthServer) findOrphaned(ctx context.Context, hash common.Hash) (*rpcerrors.ReorgInfo, error) {
+	info := &rpcerrors.ReorgInfo{}
+	for i := 0; i < maxReorgDepth; i++ {
+		block, err := rpc.GetBlockByHash(hash, false).Call(ctx, srv.C)
+		if err != nil {
+			return nil, rpcerrors.UpstreamError(err, codes.Unavailable, fmt.Sprintf("failed to get block %s", hash))
+		}
+		if block == nil {
+			// node already pruned the side chain, ancestor is unknown
+			srv.Log.Debug("Orphaned block unknown to the node", "hash", hash)
+			info.Orphaned = append(info.Orphaned, hash.Bytes())
+			return info, nil
+		}
+		canonical, err := rpc.GetBlockByNumber(block.Header.Number, false).Call(ctx, srv.C)
+		if err != nil {
+			return nil, rpcerrors.UpstreamError(err, codes.Unavailable, fmt.Sprintf("failed to get block %d", block.Header.Number))
+		}
+		if canonical == nil {
+			// upstream lags behind the one which served the block
+			return nil, status.Errorf(codes.Unavailable, "block %d is not available yet", block.Header.Number)
+		}
+		if canonical.BlockHash == hash {
+			info.AncestorId = hash.Bytes()
+			info.AncestorNumber = block.Header.Number.Uint64()
+			return info, nil
+		}
+		info.Orphaned = append(info.Orphaned, hash.Bytes())
+		if block.Header.Number.Sign() == 0 {
+			return info, nil
+		}
+		hash = block.Header.ParentHash
+	}
+	srv.Log.Warn("Common ancestor not found", "depth", maxReorgDepth)
+	return info, nil
+}
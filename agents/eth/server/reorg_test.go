@@ -0,0 +1,57 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+)
+
+func TestCheckContinuity(t *testing.T) {
+	chain := newTestChain(t, 10)
+	srv := newTestServer(t, chain.Results())
+	ctx := context.Background()
+
+	// parent matches last seen block
+	if err := srv.checkContinuity(ctx, chain.Hash(9).Bytes(), chain.Hash(9)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// last seen blocks 8 and 9 of side chain forked after block 7
+	side8 := testBlockHash(8, 1)
+	side9 := testBlockHash(9, 1)
+	chain.AddBlock(8, chain.Hash(7), side8, false)
+	chain.AddBlock(9, side8, side9, false)
+	info, ok := rpcerrors.ReorgFromError(srv.checkContinuity(ctx, side9.Bytes(), chain.Hash(9)))
+	if !ok {
+		t.Fatal("expected reorg error")
+	}
+	if len(info.Orphaned) != 2 || !bytes.Equal(info.Orphaned[0], side9.Bytes()) || !bytes.Equal(info.Orphaned[1], side8.Bytes()) {
+		t.Errorf("unexpected orphaned blocks %x", info.Orphaned)
+	}
+	if !bytes.Equal(info.AncestorId, chain.Hash(7).Bytes()) || info.AncestorNumber != 7 {
+		t.Errorf("unexpected ancestor %x %d", info.AncestorId, info.AncestorNumber)
+	}
+}
+
+func TestFindOrphanedDepthExceeded(t *testing.T) {
+	length := maxReorgDepth + 10
+	chain := newTestChain(t, length)
+	srv := newTestServer(t, chain.Results())
+
+	// side chain forked below the reorg depth
+	parent := chain.Hash(1)
+	for number := uint64(2); number < uint64(length); number++ {
+		hash := testBlockHash(number, 1)
+		chain.AddBlock(number, parent, hash, false)
+		parent = hash
+	}
+	info, err := srv.findOrphaned(context.Background(), parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Orphaned) != maxReorgDepth || info.AncestorId != nil {
+		t.Errorf("expected %d orphaned blocks without ancestor, got %d, ancestor %x", maxReorgDepth, len(info.Orphaned), info.AncestorId)
+	}
+}
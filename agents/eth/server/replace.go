@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/agents/eth/rpc"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"github.com/ubtr/ubt/go/api/proto/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// minimal fee bump in percents node requires to replace pending transaction (geth txpool.pricebump)
+const replacementBumpPercent = 10
+
+type ReplaceTransactionRequest struct {
+	Intent *services.TransactionIntent // original intent; or
+	TxId   []byte                      // id of the sent transaction
+	From   string                      // sender, required with Intent for cancellation
+	Cancel bool                        // send zero value self transfer instead of original payload
+}
+
+// get price increased by the replacement bump, rounded up
+func bumpPrice(price *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(price, big.NewInt(100+replacementBumpPercent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// fees for replacement of the original transaction: suggested fees raised to clear node replacement thresholds
+func ReplacementFees(original *types.Transaction, suggested *TxFees) *TxFees {
+	maxOf := func(a, b *big.Int) *big.Int {
+		if a.Cmp(b) >= 0 {
+			return new(big.Int).Set(a)
+		}
+		return new(big.Int).Set(b)
+	}
+	if !suggested.IsDynamic() {
+		return &TxFees{GasPrice: maxOf(suggested.GasPrice, bumpPrice(original.GasPrice()))}
+	}
+	// legacy transaction reports gas price as both tip and fee cap
+	fees := &TxFees{
+		GasTipCap: maxOf(suggested.GasTipCap, bumpPrice(original.GasTipCap())),
+		GasFeeCap: maxOf(suggested.GasFeeCap, bumpPrice(original.GasFeeCap())),
+		BaseFee:   suggested.BaseFee,
+	}
+	if fees.GasFeeCap.Cmp(fees.GasTipCap) < 0 {
+		fees.GasFeeCap.Set(fees.GasTipCap)
+	}
+	return fees
+}
+
+// create intent replacing a pending transaction with the same nonce: either speed up with the same payload or cancel;
+// called from Go only, the construct service has no replacement request
+func (srv *EthServer) ReplaceTransaction(ctx context.Context, req *ReplaceTransactionRequest) (*services.TransactionIntent, error) {
+	original := &types.Transaction{}
+	var from common.Address
+
+	if req.Intent != nil {
+		if err := original.UnmarshalBinary(req.Intent.RawData); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "failed to unmarshal raw tx: %v", err)
+		}
+		if req.Cancel {
+			if req.From == "" {
+				return nil, rpcerrors.ArgError("from", errors.New("sender is required to cancel intent"))
+			}
+			addr, err := srv.AddressFromString(req.From)
+			if err != nil {
+				return nil, err
+			}
+			from = addr
+		}
+	} else {
+		if len(req.TxId) != common.HashLength {
+			return nil, rpcerrors.ArgError("txId", fmt.Errorf("invalid tx id length %d", len(req.TxId)))
+		}
+		sent, err := rpc.GetTransactionByHash(common.BytesToHash(req.TxId)).Call(ctx, srv.C)
+		if err != nil {
+			return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get tx")
+		}
+		if sent == nil {
+			return nil, status.Errorf(codes.NotFound, "tx %x not found", req.TxId)
+		}
+		if sent.BlockNumber != nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "tx %x is already included", req.TxId)
+		}
+		original = sent.Tx
+		if req.Cancel {
+			if sent.From != nil {
+				from = *sent.From
+			} else if from, err = types.Sender(srv.Signer(), sent.Tx); err != nil {
+				return nil, status.Errorf(codes.FailedPrecondition, "failed to determine sender of tx %x: %v", req.TxId, err)
+			}
+		}
+	}
+
+	feeOpts, err := agent.FeeOptionsFromContext(ctx, &srv.Config)
+	if err != nil {
+		return nil, err
+	}
+	suggested, err := srv.SuggestFees(ctx, feeOpts)
+	if err != nil {
+		return nil, err
+	}
+	fees := ReplacementFees(original, suggested)
+
+	var tx *types.Transaction
+	if req.Cancel {
+		tx = fees.NewTx(srv.ChainId, original.Nonce(), &from, big.NewInt(0), 21000, nil)
+	} else {
+		tx = fees.NewTx(srv.ChainId, original.Nonce(), original.To(), original.Value(), original.Gas(), original.Data())
+	}
+
+	srv.Log.Debug("ReplaceTransaction", "cancel", req.Cancel, "nonce", tx.Nonce(), "tipCap", tx.GasTipCap(), "feeCap", tx.GasFeeCap())
+
+	return srv.txToIntent(tx, fees.ExpectedFee(tx.Gas()))
+}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCancelWithUnknownSender(t *testing.T) {
+	txId := common.HexToHash("0x01")
+	// pending tx reported without sender and signature
+	srv := newTestServer(t, map[string]any{
+		"eth_getTransactionByHash": map[string]any{
+			"hash": txId.Hex(), "type": "0x0", "nonce": "0x5", "gas": "0x5208", "gasPrice": "0x1", "value": "0x0", "input": "0x",
+			"to": "0x0000000000000000000000000000000000000002", "v": "0x0", "r": "0x0", "s": "0x0",
+		},
+	})
+
+	_, err := srv.ReplaceTransaction(context.Background(), &ReplaceTransactionRequest{TxId: txId.Bytes(), Cancel: true})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected failed precondition, got %v", err)
+	}
+}
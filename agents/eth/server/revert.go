@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/ubtr/ubt-go/agents/eth/rpc"
+	ethtypes "github.com/ubtr/ubt-go/agents/eth/types"
+)
+
+// decode revert data: Error(string), Panic(uint256) or selector of a custom error
+func DecodeRevertData(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		return reason
+	}
+	if len(data) >= 4 {
+		return fmt.Sprintf("custom error 0x%x", data[:4])
+	}
+	return hexutil.Encode(data)
+}
+
+// get revert reason from eth_call error, returns false if error is not a revert
+func RevertReasonFromError(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+	var dataErr gethrpc.DataError
+	if errors.As(err, &dataErr) {
+		if hexData, ok := dataErr.ErrorData().(string); ok {
+			if data, decErr := hexutil.Decode(hexData); decErr == nil && len(data) > 0 {
+				return DecodeRevertData(data), true
+			}
+		}
+	}
+	msg := err.Error()
+	if strings.Contains(msg, "execution reverted") {
+		reason := strings.TrimPrefix(msg, "execution reverted")
+		return strings.TrimSpace(strings.TrimPrefix(reason, ":")), true
+	}
+	return "", false
+}
+
+// replay included transaction with eth_call on the state of the previous block to get its revert reason;
+// transactions earlier in the same block are not applied so result is a best effort
+func (srv *EthServer) ReplayRevertReason(ctx context.Context, tx *ethtypes.RpcTx, blockNumber uint64) (string, error) {
+	if blockNumber == 0 {
+		return "", nil
+	}
+	msg := ethereum.CallMsg{
+		To:    tx.Tx.To(),
+		Gas:   tx.Tx.Gas(),
+		Value: tx.Tx.Value(),
+		Data:  tx.Tx.Data(),
+	}
+	if tx.From != nil {
+		msg.From = *tx.From
+	}
+	_, err := rpc.AdoptClient(srv.C).CallContract(ctx, msg, new(big.Int).SetUint64(blockNumber-1))
+	if reason, ok := RevertReasonFromError(err); ok {
+		return reason, nil
+	}
+	return "", err
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestDecodeRevertData(t *testing.T) {
+	// Error("insufficient balance")
+	data := hexutil.MustDecode("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"0000000000000000000000000000000000000000000000000000000000000014" +
+		"696e73756666696369656e742062616c616e6365000000000000000000000000")
+	if reason := DecodeRevertData(data); reason != "insufficient balance" {
+		t.Errorf("expected 'insufficient balance', got '%s'", reason)
+	}
+
+	custom := hexutil.MustDecode("0xe450d38c0000000000000000000000000000000000000000000000000000000000000001")
+	if reason := DecodeRevertData(custom); reason != "custom error 0xe450d38c" {
+		t.Errorf("expected custom error selector, got '%s'", reason)
+	}
+
+	if reason := DecodeRevertData(nil); reason != "" {
+		t.Errorf("expected empty reason, got '%s'", reason)
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethtypes "github.com/ubtr/ubt-go/agents/eth/types"
+	"github.com/ubtr/ubt/go/api/proto"
+)
+
+const BlockOpFeeRecipient byte = 0x02
+
+// block producer income paid by the protocol
+type BlockReward struct {
+	To     common.Address
+	Amount *big.Int
+}
+
+// fees of block transactions split into what was paid to the fee recipient and what was burned
+type BlockFees struct {
+	Tips   *big.Int // priority fees, paid to the fee recipient
+	Burned *big.Int // base fee and blob fee, burned
+}
+
+// sum fees of block transactions from their receipts
+func CalcBlockFees(block *ethtypes.HeaderWithBody, receipts map[uint]*ethtypes.RpcReceipt) BlockFees {
+	fees := BlockFees{Tips: new(big.Int), Burned: new(big.Int)}
+	baseFee := block.Header.BaseFee
+	for _, tx := range block.Body.Transactions {
+		receipt := receipts[uint(tx.TransactionIndex)]
+		if receipt == nil {
+			continue
+		}
+		gasUsed := new(big.Int).SetUint64(uint64(receipt.GasUsed))
+		gasPrice, err := receipt.GasPrice(tx.Tx, baseFee)
+		if err != nil {
+			continue
+		}
+		tipPerGas := gasPrice
+		if baseFee != nil {
+			tipPerGas = new(big.Int).Sub(gasPrice, baseFee)
+			fees.Burned.Add(fees.Burned, new(big.Int).Mul(gasUsed, baseFee))
+		}
+		fees.Tips.Add(fees.Tips, new(big.Int).Mul(gasUsed, tipPerGas))
+		if receipt.BlobGasPrice != nil && receipt.BlobGasUsed > 0 {
+			fees.Burned.Add(fees.Burned, new(big.Int).Mul(new(big.Int).SetUint64(uint64(receipt.BlobGasUsed)), receipt.BlobGasPrice.ToInt()))
+		}
+	}
+	return fees
+}
+
+// default crediting: priority fees go to the block coinbase; proof of stake issuance is paid by beacon chain withdrawals
+func DefaultBlockRewards(header *types.Header, fees BlockFees) []BlockReward {
+	if fees.Tips.Sign() <= 0 {
+		return nil
+	}
+	return []BlockReward{{To: header.Coinbase, Amount: fees.Tips}}
+}
+
+// fee recipient income as native transfers within one synthetic operation, nil if there is no income
+func (c *BlockConverter) ConvertBlockRewards(block *ethtypes.HeaderWithBody, receipts map[uint]*ethtypes.RpcReceipt, idx uint32) *proto.Transaction {
+	blockRewards := DefaultBlockRewards
+	if c.Srv.Extensions.BlockRewards != nil {
+		blockRewards = c.Srv.Extensions.BlockRewards
+	}
+	rewards := blockRewards(&block.Header, CalcBlockFees(block, receipts))
+	if len(rewards) == 0 {
+		return nil
+	}
+
+	opId := BlockOperationId(block, BlockOpFeeRecipient)
+	var transfers []*proto.Transfer
+	for i, reward := range rewards {
+		to := reward.To
+		transfers = append(transfers, &proto.Transfer{
+			Id:     binary.BigEndian.AppendUint32(append([]byte{}, opId...), uint32(i)),
+			TxId:   opId,
+			OpId:   opId,
+			To:     c.Srv.AddressToString(&to),
+			Status: TransferStatusSuccess,
+			Amount: &proto.CurrencyAmount{CurrencyId: "", Value: &proto.Uint256{Data: reward.Amount.Bytes()}},
+		})
+	}
+	return &proto.Transaction{
+		Id:         opId,
+		BlockId:    block.BlockHash.Bytes(),
+		Idx:        idx,
+		Type:       TxTypeBlockOperation,
+		Fee:        &proto.Uint256{Data: []byte{0}},
+		Amount:     &proto.Uint256{Data: []byte{0}},
+		Transfers:  transfers,
+		Operations: []*proto.Operation{{Id: opId, Type: uint32(BlockOpFeeRecipient)}},
+	}
+}
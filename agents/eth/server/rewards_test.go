@@ -0,0 +1,37 @@
+package server
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethtypes "github.com/ubtr/ubt-go/agents/eth/types"
+)
+
+func TestCalcBlockFees(t *testing.T) {
+	block := &ethtypes.HeaderWithBody{
+		Header: types.Header{BaseFee: big.NewInt(10), Coinbase: common.HexToAddress("0x01")},
+		Body: ethtypes.RpcBody{Transactions: []*ethtypes.RpcTx{
+			{Tx: types.NewTx(&types.DynamicFeeTx{GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(20)}), TxExtraInfo: ethtypes.TxExtraInfo{TransactionIndex: 0}},
+			{Tx: types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(15)}), TxExtraInfo: ethtypes.TxExtraInfo{TransactionIndex: 1}},
+		}},
+	}
+	receipts := map[uint]*ethtypes.RpcReceipt{
+		0: {GasUsed: 100, EffectiveGasPrice: (*hexutil.Big)(big.NewInt(12))},
+		// no effective gas price reported, taken from transaction
+		1: {GasUsed: 10, TransactionIndex: 1},
+	}
+
+	fees := CalcBlockFees(block, receipts)
+	// tips: 100*2 + 10*5, burned: 110*10
+	if fees.Tips.Int64() != 250 || fees.Burned.Int64() != 1100 {
+		t.Fatalf("expected tips 250 burned 1100, got %s %s", fees.Tips, fees.Burned)
+	}
+
+	rewards := DefaultBlockRewards(&block.Header, fees)
+	if len(rewards) != 1 || rewards[0].To != block.Header.Coinbase || rewards[0].Amount.Int64() != 250 {
+		t.Errorf("unexpected rewards %v", rewards)
+	}
+}
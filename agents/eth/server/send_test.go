@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+)
+
+func TestIsKnownTxError(t *testing.T) {
+	known := []error{
+		errors.New("already known"),
+		errors.New("Known transaction: 0x01"),
+		fmt.Errorf("upstream a: %w", errors.Join(errors.New("connection refused"), errors.New("ALREADY_EXISTS: already known"))),
+	}
+	for _, err := range known {
+		if !IsKnownTxError(err) {
+			t.Errorf("expected '%v' to be known tx error", err)
+		}
+	}
+	if IsKnownTxError(errors.New("nonce too low")) || IsKnownTxError(errors.New("replacement transaction underpriced")) {
+		t.Error("unexpected known tx error")
+	}
+}
+
+func TestSendRawTxNonceTooLow(t *testing.T) {
+	to := common.HexToAddress("0x02")
+	tx := types.NewTx(&types.LegacyTx{Nonce: 5, To: &to, Gas: 21000, GasPrice: big.NewInt(1), Value: big.NewInt(0)})
+	rpcTx := map[string]any{
+		"hash": tx.Hash().Hex(), "type": "0x0", "nonce": "0x5", "gas": "0x5208", "gasPrice": "0x1", "value": "0x0", "input": "0x",
+		"to": to.Hex(), "v": "0x0", "r": "0x0", "s": "0x0", "blockNumber": "0x10",
+	}
+
+	// this very tx is already included, nodes word the error differently
+	for _, msg := range []string{"nonce too low", "Nonce too low: next nonce 6, tx nonce 5", "nonce has already been used"} {
+		srv := newTestServer(t, map[string]any{
+			"eth_sendRawTransaction":   &testRpcError{code: -32000, msg: msg},
+			"eth_getTransactionByHash": rpcTx,
+		})
+		if err := srv.sendRawTx(context.Background(), tx); err != nil {
+			t.Errorf("%s: expected included tx to be sent, got %v", msg, err)
+		}
+	}
+
+	// nonce is used by other tx
+	srv := newTestServer(t, map[string]any{
+		"eth_sendRawTransaction":   &testRpcError{code: -32000, msg: "Nonce too low"},
+		"eth_getTransactionByHash": nil,
+	})
+	err := srv.sendRawTx(context.Background(), tx)
+	if rpcerrors.ClassifyUpstreamError(err) != rpcerrors.ReasonNonceTooLow {
+		t.Errorf("expected nonce too low error, got %v", err)
+	}
+}
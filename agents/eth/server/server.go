@@ -7,9 +7,12 @@ import (
 	"log"
 	"log/slog"
 	"math/big"
+	"sync/atomic"
 
 	"github.com/eko/gocache/lib/v4/cache"
 	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/agents/eth/nonce"
+	"github.com/ubtr/ubt-go/agents/eth/registry"
 	ethrpc "github.com/ubtr/ubt-go/agents/eth/rpc"
 	ethtypes "github.com/ubtr/ubt-go/agents/eth/types"
 	"github.com/ubtr/ubt-go/blockchain/eth"
@@ -20,6 +23,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/ubtr/ubt-go/blockchain"
@@ -27,6 +31,8 @@ import (
 
 	"github.com/ubtr/ubt/go/api/proto"
 	"github.com/ubtr/ubt/go/api/proto/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func init() {
@@ -40,6 +46,10 @@ type Extensions struct {
 	AddressFromString   func(address string) (common.Address, error)
 	AddressToString     func(address common.Address) string
 	BlockFinalityStatus func(block *proto.Block) proto.FinalityStatus
+	// chain specific source of safe and finalized heads; node block tags with depth fallback if nil
+	NewHeadsFetcher func(srv *EthServer) HeadsFetcher
+	// how block producer income is credited when fee recipient income is enabled; DefaultBlockRewards if nil
+	BlockRewards func(header *types.Header, fees BlockFees) []BlockReward
 }
 
 type EthServer struct {
@@ -47,16 +57,29 @@ type EthServer struct {
 	services.UnimplementedUbtBlockServiceServer
 	services.UnimplementedUbtConstructServiceServer
 	services.UnimplementedUbtCurrencyServiceServer
+	services.UnimplementedUbtBalanceServiceServer
 	C             *client.BalancedClient
+	TraceC        *client.BalancedClient // upstreams with trace support, nil if internal transfers are disabled
 	Config        agent.ChainConfig
 	Chain         blockchain.Blockchain
 	ChainId       *big.Int
 	CurrencyCache cache.CacheInterface[*proto.Currency]
+	Currencies    *registry.Registry // persistent currency metadata, nil if not configured
+	Nonces        *nonce.Manager
+	Heads         *HeadTracker
 	Log           *slog.Logger
 	Extensions    Extensions
+
+	blockReceiptsUnsupported atomic.Bool // upstream does not support eth_getBlockReceipts
+	balances                 balanceState
 }
 
 func InitServer(ctx context.Context, config *agent.ChainConfig) *EthServer {
+	return InitServerWithExtensions(ctx, config, Extensions{})
+}
+
+// init server for eth-like chain; extensions are set before any background processing starts
+func InitServerWithExtensions(ctx context.Context, config *agent.ChainConfig, extensions Extensions) *EthServer {
 
 	chainIdStr := config.ChainType + ":" + config.ChainNetwork
 	logger := slog.With("chain", chainIdStr)
@@ -75,6 +98,8 @@ func InitServer(ctx context.Context, config *agent.ChainConfig) *EthServer {
 	client := client.NewBalancedClient(peers, []any{"chain", chainIdStr}) //client.DialContext(ctx, config.LimitRPS, commons.EitherStr())
 	client.Start()
 
+	traceClient := initTraceClient(config, chainIdStr, logger)
+
 	chainId, err := ethrpc.ChainId().Call(ctx, client)
 	if err != nil {
 		panic(err)
@@ -84,12 +109,66 @@ func InitServer(ctx context.Context, config *agent.ChainConfig) *EthServer {
 		panic(fmt.Sprintf("Unsupported chain type '%s'", config.ChainType))
 	}
 
-	var srv = EthServer{C: client, Config: *config, ChainId: chainId, Chain: *blockchain, CurrencyCache: ubtcache.NewCache[*proto.Currency](), Log: logger}
+	var nonceStore nonce.Store = nonce.NewMemoryStore()
+	if config.NonceDb != "" {
+		nonceStore, err = nonce.OpenSqlStore(config.NonceDb)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	var srv = EthServer{C: client, TraceC: traceClient, Config: *config, ChainId: chainId, Chain: *blockchain, CurrencyCache: ubtcache.NewCache[*proto.Currency](),
+		Nonces: nonce.NewManager(nonceStore, chainIdStr, nonce.DefaultTtl), Log: logger, Extensions: extensions}
+
+	if err := checkCurrencyRegistryConfig(config); err != nil {
+		panic(err)
+	}
+	if config.CurrencyDb != "" {
+		if err := srv.initCurrencyRegistry(ctx); err != nil {
+			panic(err)
+		}
+	}
+
+	var fetchHeads HeadsFetcher
+	if extensions.NewHeadsFetcher != nil {
+		fetchHeads = extensions.NewHeadsFetcher(&srv)
+	} else {
+		fetchHeads = NewTagHeadsFetcher(client, config.SafeDepth, config.FinalityDepth, logger).Fetch
+	}
+	srv.Heads = NewHeadTracker(fetchHeads, config.HeadPollInterval, logger).Start(ctx)
+	for _, url := range config.RpcUrls {
+		if url.WsUrl != "" {
+			// one subscription is enough to learn about new blocks early, polling covers the rest
+			go srv.subscribeNewHeads(ctx, url.WsUrl)
+			break
+		}
+	}
 
 	srv.Log.Info("Connected")
 	return &srv
 }
 
+// client balancing between upstreams advertising trace support, used to trace internal transfers
+func initTraceClient(config *agent.ChainConfig, chainIdStr string, logger *slog.Logger) *client.BalancedClient {
+	if !config.InternalTransfers {
+		return nil
+	}
+	var peers []*client.ClientConfig
+	for _, url := range config.RpcUrls {
+		if !url.Trace {
+			continue
+		}
+		// separate metrics for the same upstream used by main client
+		upstreamLabel := commons.EitherStr(url.Name, url.Url) + "/trace"
+		peers = append(peers, &client.ClientConfig{Url: url.Url, LimitRps: url.LimitRps, Labels: []any{"chain", chainIdStr, "upstream", upstreamLabel}})
+	}
+	if len(peers) == 0 {
+		panic("Internal transfers enabled but no upstreams with trace support configured")
+	}
+	logger.Info(fmt.Sprintf("Tracing internal transfers with %d upstreams", len(peers)))
+	return client.NewBalancedClient(peers, []any{"chain", chainIdStr, "trace", "true"}).Start()
+}
+
 func (srv *EthServer) String() string {
 	return fmt.Sprintf("EthServer{%s:%s}", srv.Config.ChainType, srv.Config.ChainNetwork)
 }
@@ -131,7 +210,7 @@ func (srv *EthServer) GetChain(ctx context.Context, chainId *proto.ChainId) (*pr
 		FinalizedHeight: 20, //FIXME: this is wrong assumption
 		MsPerBlock:      3000,
 		SupportedServices: []proto.Chain_ChainSupportedServices{
-			proto.Chain_BLOCK, proto.Chain_CONSTRUCT, proto.Chain_CURRENCIES},
+			proto.Chain_BLOCK, proto.Chain_CONSTRUCT, proto.Chain_CURRENCIES, proto.Chain_BALANCES},
 	}, nil
 }
 
@@ -147,7 +226,7 @@ func (srv *EthServer) ListChains(req *services.ListChainsRequest, s services.Ubt
 		FinalizedHeight: 20,
 		MsPerBlock:      3000,
 		SupportedServices: []proto.Chain_ChainSupportedServices{
-			proto.Chain_BLOCK, proto.Chain_CONSTRUCT, proto.Chain_CURRENCIES},
+			proto.Chain_BLOCK, proto.Chain_CONSTRUCT, proto.Chain_CURRENCIES, proto.Chain_BALANCES},
 	})
 	if err != nil {
 		return err
@@ -175,6 +254,9 @@ func (srv *EthServer) GetBlock(ctx context.Context, req *services.BlockRequest)
 	if err != nil {
 		return nil, err
 	}
+	if block == nil {
+		return nil, status.Errorf(codes.NotFound, "block %x not found", req.Id)
+	}
 	converter := &BlockConverter{Config: &srv.Config, Client: srv.C, Srv: srv, Ctx: ctx, Log: srv.Log.With("block", req.Id)}
 	b, err := converter.EthBlockToProto(block)
 	if err != nil {
@@ -187,27 +269,57 @@ func (srv *EthServer) GetBlock(ctx context.Context, req *services.BlockRequest)
 func (srv *EthServer) ListBlocks(req *services.ListBlocksRequest, res services.UbtBlockService_ListBlocksServer) error {
 	srv.Log.Debug(fmt.Sprintf("ListBlocks from %d, count = %v\n", req.StartNumber, req.Count))
 
-	// get top block number
-	topBlockNumber, err := ethrpc.GetBlockNumber().Call(res.Context(), srv.C)
+	lastSeenId, err := agent.LastBlockIdFromContext(res.Context())
+	if err != nil {
+		return err
+	}
+	follow, err := agent.FollowFromContext(res.Context())
 	if err != nil {
 		return err
 	}
 
-	var endNumber uint64 = 0
-	if (req.Count == nil) || (*req.Count == 0) {
-		endNumber = req.StartNumber + 10
-	} else {
-		endNumber = req.StartNumber + *req.Count
+	var count uint64 = 10
+	if req.Count != nil && *req.Count > 0 {
+		count = *req.Count
+	}
+
+	if follow {
+		return srv.followBlocks(req.StartNumber, count, req.FinalityStatus, lastSeenId, res)
+	}
+	_, _, err = srv.sendBlocks(req.StartNumber, count, req.FinalityStatus, lastSeenId, res)
+	return err
+}
+
+// send up to count blocks starting from startNumber having at least requested finality;
+// returns number of sent blocks and id of the last one
+func (srv *EthServer) sendBlocks(startNumber uint64, count uint64, finality proto.FinalityStatus, lastSeenId []byte, res services.UbtBlockService_ListBlocksServer) (uint64, []byte, error) {
+	// get top block number
+	topBlockNumber, err := ethrpc.GetBlockNumber().Call(res.Context(), srv.C)
+	if err != nil {
+		return 0, nil, err
+	}
+	// do not fetch blocks which are known to be below requested finality
+	if finality > proto.FinalityStatus_FINALITY_STATUS_UNSAFE {
+		heads, err := srv.Heads.Heads(res.Context())
+		if err != nil {
+			return 0, nil, err
+		}
+		if finality >= proto.FinalityStatus_FINALITY_STATUS_FINALIZED {
+			topBlockNumber = min(topBlockNumber, heads.Finalized)
+		} else {
+			topBlockNumber = min(topBlockNumber, heads.Safe)
+		}
 	}
-	endNumber = min(endNumber, topBlockNumber+1)
-	srv.Log.Debug("Block range", "startNumber", req.StartNumber, "endNumber", endNumber)
-	if req.StartNumber >= endNumber {
-		return rpcerrors.ErrBlockOutOfRange
+
+	endNumber := min(startNumber+count, topBlockNumber+1)
+	srv.Log.Debug("Block range", "startNumber", startNumber, "endNumber", endNumber)
+	if startNumber >= endNumber {
+		return 0, nil, rpcerrors.ErrBlockOutOfRange
 	}
 
-	blockReqs := []*jsonrpc.RpcCall[ethtypes.HeaderWithBody]{}
+	blockReqs := []*jsonrpc.RpcCall[*ethtypes.HeaderWithBody]{}
 	var batch jsonrpc.RpcBatch
-	for i := req.StartNumber; i < endNumber; i++ {
+	for i := startNumber; i < endNumber; i++ {
 		c := ethrpc.GetBlockByNumber(big.NewInt(int64(i)), true)
 		c.AddToBatch(&batch)
 		blockReqs = append(blockReqs, c)
@@ -215,39 +327,59 @@ func (srv *EthServer) ListBlocks(req *services.ListBlocksRequest, res services.U
 
 	err = batch.Call(res.Context(), srv.C)
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
 
 	srv.Log.Debug("Blocks received", "count", len(blockReqs))
 
+	var sent uint64
+	var prevHash common.Hash
 	for idx, blockReq := range blockReqs {
 		err := blockReq.ProcessRes(res.Context())
 		if err != nil {
-			return err
+			return sent, prevHash.Bytes(), err
+		}
+		blockRes := *blockReq.Response
+		if blockRes == nil {
+			// lagging upstream has no block yet
+			if idx == 0 {
+				return 0, nil, rpcerrors.ErrBlockOutOfRange
+			}
+			break
+		}
+		if idx == 0 && lastSeenId != nil {
+			if err := srv.checkContinuity(res.Context(), lastSeenId, blockRes.Header.ParentHash); err != nil {
+				return sent, nil, err
+			}
+		} else if idx > 0 && blockRes.Header.ParentHash != prevHash {
+			// reorg while fetching, client will detect it on the next call
+			srv.Log.Warn("Block does not continue previous one, stopping", "number", blockRes.Header.Number, "parent", blockRes.Header.ParentHash, "prev", prevHash)
+			break
 		}
-		blockRes := blockReq.Response
 		converter := &BlockConverter{Config: &srv.Config, Client: srv.C, Ctx: res.Context(), Srv: srv, Log: srv.Log.With("block", blockRes.Header.Hash())}
 		block, err := converter.EthBlockToProto(blockRes)
 		if err != nil {
 			srv.Log.Error("Error converting block", "error", err)
-			return err
+			return sent, prevHash.Bytes(), err
 		}
-		if block.Header.FinalityStatus < req.FinalityStatus {
+		if block.Header.FinalityStatus < finality {
 			if idx > 0 {
 				break
 			} else {
-				return rpcerrors.ErrBlockOutOfRange
+				return 0, nil, rpcerrors.ErrBlockOutOfRange
 			}
 		}
 		srv.Log.Debug("Send processed block", "txCount", len(block.Transactions))
 		err = res.Send(block)
 		if err != nil {
 			srv.Log.Error("Error sending block", "error", err)
-			return err
+			return sent, prevHash.Bytes(), err
 		}
+		prevHash = blockRes.BlockHash
+		sent++
 	}
 	srv.Log.Debug("Done sending blocks")
-	return nil
+	return sent, prevHash.Bytes(), nil
 }
 
 type NodeSyncInfo struct {
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/agents/eth/rpc"
+	"github.com/ubtr/ubt-go/commons/jsonrpc"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"github.com/ubtr/ubt/go/api/proto/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ agent.Simulator = (*EthServer)(nil)
+
+var pendingBlock = big.NewInt(int64(gethrpc.PendingBlockNumber))
+
+// replay intent with eth_call and eth_estimateGas on pending state in one batch
+func (srv *EthServer) SimulateIntent(ctx context.Context, req *agent.SimulationRequest) (*agent.SimulationResult, error) {
+	if req.Intent == nil {
+		return nil, rpcerrors.ArgError("intent", errors.New("intent is required"))
+	}
+	tx := &types.Transaction{}
+	if err := tx.UnmarshalBinary(req.Intent.RawData); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to unmarshal raw tx: %v", err)
+	}
+	var from common.Address
+	if len(req.Signatures) > 0 {
+		signed, err := srv.signedTx(tx, req.Signatures[0])
+		if err != nil {
+			return nil, err
+		}
+		from, _ = types.Sender(srv.Signer(), signed)
+	} else {
+		var err error
+		if from, err = srv.AddressFromString(req.From); err != nil {
+			return nil, err
+		}
+	}
+
+	msg := ethereum.CallMsg{From: from, To: tx.To(), Value: tx.Value(), Data: tx.Data()}
+	var batch jsonrpc.RpcBatch
+	estimateCall := rpc.EstimateGas(msg, pendingBlock)
+	estimateCall.AddToBatch(&batch)
+	// executed with intent gas limit so insufficient limit shows up as failure
+	msg.Gas = tx.Gas()
+	call := rpc.Call(msg, pendingBlock)
+	call.AddToBatch(&batch)
+	if err := batch.Call(ctx, srv.C); err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to simulate tx")
+	}
+
+	if err := call.ProcessRes(ctx); err != nil {
+		reason, ok := executionFailure(err)
+		if !ok {
+			return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to simulate tx")
+		}
+		srv.Log.Debug("simulation failed", "from", from, "reason", reason)
+		return &agent.SimulationResult{Success: false, RevertReason: reason}, nil
+	}
+	ret := &agent.SimulationResult{Success: true, ReturnData: *call.Response}
+	if err := estimateCall.ProcessRes(ctx); err == nil {
+		ret.GasUsed = *estimateCall.Response
+	} else {
+		srv.Log.Debug("failed to estimate simulated tx gas", "err", err)
+	}
+	return ret, nil
+}
+
+// json-rpc error code of reverted execution
+const rpcCodeExecutionFail = 3
+
+// lowercase fragments of evm errors; nodes report them with generic server error code
+// together with node failures like "header not found" or "missing trie node"
+var executionErrors = []string{
+	"out of gas",
+	"gas required exceeds allowance",
+	"insufficient funds",
+	"invalid opcode",
+	"invalid jump destination",
+	"stack underflow",
+	"stack overflow",
+	"write protection",
+	"max code size exceeded",
+	"max initcode size exceeded",
+	"contract address collision",
+	"intrinsic gas too low",
+}
+
+// get revert reason or execution error (out of gas, insufficient funds), false for upstream failures
+func executionFailure(err error) (string, bool) {
+	if reason, ok := RevertReasonFromError(err); ok {
+		if reason == "" {
+			reason = "execution reverted"
+		}
+		return reason, true
+	}
+	var rpcErr gethrpc.Error
+	if !errors.As(err, &rpcErr) {
+		return "", false
+	}
+	if rpcErr.ErrorCode() == rpcCodeExecutionFail {
+		return rpcErr.Error(), true
+	}
+	msg := strings.ToLower(rpcErr.Error())
+	for _, fragment := range executionErrors {
+		if strings.Contains(msg, fragment) {
+			return rpcErr.Error(), true
+		}
+	}
+	return "", false
+}
+
+func (srv *EthServer) signedTx(tx *types.Transaction, signature []byte) (*types.Transaction, error) {
+	if len(signature) != crypto.SignatureLength {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid signature length %d", len(signature))
+	}
+	signed, err := tx.WithSignature(srv.Signer(), signature)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid signature: %v", err)
+	}
+	if _, err := types.Sender(srv.Signer(), signed); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid signature: %v", err)
+	}
+	return signed, nil
+}
+
+// check signed transaction without broadcasting: signature must recover the sender and nonce must not be used yet
+func (srv *EthServer) dryRunSend(ctx context.Context, tx *types.Transaction) (*services.TransactionSendResponse, error) {
+	from, err := types.Sender(srv.Signer(), tx)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid signature: %v", err)
+	}
+	chainNonce, err := rpc.AdoptClient(srv.C).PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get nonce")
+	}
+	if tx.Nonce() < chainNonce {
+		return nil, rpcerrors.ReasonError(rpcerrors.ReasonNonceTooLow, fmt.Sprintf("nonce too low: tx %d, account %d", tx.Nonce(), chainNonce), nil)
+	}
+	if tx.Nonce() > chainNonce {
+		// intents reserved earlier are not sent yet, tx stays queued until the gap is filled
+		srv.Log.Debug("dry run nonce gap", "from", from, "nonce", tx.Nonce(), "chainNonce", chainNonce)
+	}
+	return &services.TransactionSendResponse{Id: tx.Hash().Bytes()}, nil
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt/go/api/proto/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testRpcError struct {
+	code int
+	msg  string
+}
+
+func (e *testRpcError) Error() string  { return e.msg }
+func (e *testRpcError) ErrorCode() int { return e.code }
+
+func TestExecutionFailure(t *testing.T) {
+	if reason, ok := executionFailure(errors.New("execution reverted: paused")); !ok || reason != "paused" {
+		t.Errorf("expected revert reason 'paused', got '%s' %v", reason, ok)
+	}
+	if reason, ok := executionFailure(&testRpcError{code: -32000, msg: "out of gas"}); !ok || reason != "out of gas" {
+		t.Errorf("expected execution failure 'out of gas', got '%s' %v", reason, ok)
+	}
+	if _, ok := executionFailure(&testRpcError{code: rpcCodeExecutionFail, msg: "invalid opcode: INVALID"}); !ok {
+		t.Error("expected execution failure for code 3")
+	}
+	// generic server error of node failures
+	for _, msg := range []string{"header not found", "missing trie node 1234 (path )"} {
+		if _, ok := executionFailure(&testRpcError{code: -32000, msg: msg}); ok {
+			t.Errorf("expected '%s' not to be execution failure", msg)
+		}
+	}
+	// rate limits and transport errors are not simulation outcome
+	if _, ok := executionFailure(&testRpcError{code: -32005, msg: "limit exceeded"}); ok {
+		t.Error("expected upstream error not to be execution failure")
+	}
+	if _, ok := executionFailure(errors.New("connection refused")); ok {
+		t.Error("expected transport error not to be execution failure")
+	}
+}
+
+func TestSimulateIntent(t *testing.T) {
+	to := common.HexToAddress("0x02")
+	tx := types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(1), Nonce: 3, To: &to, Gas: 21000, GasFeeCap: big.NewInt(10), GasTipCap: big.NewInt(1)})
+	rawTx, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &agent.SimulationRequest{From: "0x0000000000000000000000000000000000000001", Intent: &services.TransactionIntent{RawData: rawTx}}
+
+	srv := newTestServer(t, map[string]any{
+		"eth_estimateGas": "0x5208",
+		"eth_call":        &testRpcError{code: -32000, msg: "out of gas"},
+	})
+	res, err := srv.SimulateIntent(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || res.RevertReason != "out of gas" {
+		t.Errorf("expected failed simulation with 'out of gas', got %+v", res)
+	}
+
+	// node failure is not a simulation outcome
+	srv = newTestServer(t, map[string]any{
+		"eth_estimateGas": "0x5208",
+		"eth_call":        &testRpcError{code: -32000, msg: "header not found"},
+	})
+	if _, err := srv.SimulateIntent(context.Background(), req); status.Code(err) != codes.Unavailable {
+		t.Errorf("expected unavailable error, got %v", err)
+	}
+
+	srv = newTestServer(t, map[string]any{
+		"eth_estimateGas": "0x5208",
+		"eth_call":        "0x01",
+	})
+	res, err = srv.SimulateIntent(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.GasUsed != 21000 || !bytes.Equal(res.ReturnData, []byte{1}) {
+		t.Errorf("expected successful simulation, got %+v", res)
+	}
+}
+
+func TestSignedTx(t *testing.T) {
+	srv := &EthServer{ChainId: big.NewInt(1)}
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to := common.HexToAddress("0x02")
+	tx := types.NewTx(&types.DynamicFeeTx{ChainID: srv.ChainId, Nonce: 3, To: &to, Gas: 21000, GasFeeCap: big.NewInt(10), GasTipCap: big.NewInt(1)})
+	signature, err := crypto.Sign(srv.Signer().Hash(tx).Bytes(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := srv.signedTx(tx, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, _ := types.Sender(srv.Signer(), signed); from != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("unexpected sender %s", from.Hex())
+	}
+	if _, err := srv.signedTx(tx, signature[:10]); err == nil {
+		t.Fatal("expected invalid signature error")
+	}
+}
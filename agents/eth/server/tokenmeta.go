@@ -0,0 +1,130 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/agents/eth/contracts/erc20"
+	"github.com/ubtr/ubt-go/agents/eth/rpc"
+	"github.com/ubtr/ubt-go/blockchain"
+	"github.com/ubtr/ubt-go/commons/jsonrpc"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"google.golang.org/grpc/codes"
+)
+
+var _ agent.TokenMetadataProvider = (*EthServer)(nil)
+
+// metadata of erc-20 currency; other currencies are rejected with rpcerrors.ErrInvalidCurrency
+func (srv *EthServer) GetTokenMetadata(ctx context.Context, currencyId string) (*agent.TokenMetadata, error) {
+	id, err := blockchain.UChainCurrencyIdromString(currencyId)
+	if err != nil {
+		return nil, err
+	}
+	if id.Address == "" || id.Token != "" {
+		return nil, rpcerrors.ErrInvalidCurrency
+	}
+	addr, err := srv.AddressFromString(id.Address)
+	if err != nil {
+		return nil, err
+	}
+	return srv.FetchTokenMetadata(ctx, addr)
+}
+
+// fetch token metadata with one json-rpc batch. Methods which revert or return nothing are treated as not implemented,
+// contract without decimals is not a token and gets rpcerrors.ErrInvalidCurrency;
+// upstream failures are reported as Unavailable so they are not mistaken for a missing token
+func (srv *EthServer) FetchTokenMetadata(ctx context.Context, token common.Address) (*agent.TokenMetadata, error) {
+	tokenAbi, err := erc20.Erc20MetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	methods := []string{"name", "symbol", "decimals", "totalSupply"}
+	var batch jsonrpc.RpcBatch
+	calls := make([]*jsonrpc.RpcCall[[]byte], len(methods))
+	for i, method := range methods {
+		data, err := tokenAbi.Pack(method)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = rpc.Call(ethereum.CallMsg{To: &token, Data: data}, nil)
+		calls[i].AddToBatch(&batch)
+	}
+	if err := batch.Call(ctx, srv.C); err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get token metadata")
+	}
+
+	results := make([][]byte, len(methods))
+	for i, call := range calls {
+		if err := call.ProcessRes(ctx); err != nil {
+			if _, reverted := RevertReasonFromError(err); !reverted {
+				return nil, rpcerrors.UpstreamError(err, codes.Unavailable, fmt.Sprintf("failed to get token %s", methods[i]))
+			}
+			srv.Log.Debug("token method reverted", "token", token, "method", methods[i], "err", err)
+			continue
+		}
+		results[i] = *call.Response
+	}
+
+	decimals, ok := decodeTokenDecimals(results[2])
+	if !ok {
+		srv.Log.Debug("contract has no decimals, not a token", "token", token)
+		return nil, rpcerrors.ErrInvalidCurrency
+	}
+	meta := &agent.TokenMetadata{
+		Name:     decodeTokenString(results[0]),
+		Symbol:   decodeTokenString(results[1]),
+		Decimals: decimals,
+	}
+	if len(results[3]) >= 32 {
+		meta.TotalSupply = new(big.Int).SetBytes(results[3][:32])
+	}
+	return meta, nil
+}
+
+// decimals are uint8 by the standard, but some tokens declare them as uint256
+func decodeTokenDecimals(data []byte) (uint8, bool) {
+	if len(data) < 32 {
+		return 0, false
+	}
+	decimals := new(big.Int).SetBytes(data[:32])
+	if !decimals.IsUint64() || decimals.Uint64() > 255 {
+		return 0, false
+	}
+	return uint8(decimals.Uint64()), true
+}
+
+var stringType, _ = abi.NewType("string", "", nil)
+var stringArgs = abi.Arguments{{Type: stringType}}
+
+// decode name or symbol returned either as abi string or as bytes32 (e.g. MKR, SAI), empty if undecodable
+func decodeTokenString(data []byte) string {
+	var str string
+	if len(data) == 32 {
+		str = string(bytes.TrimRight(data, "\x00"))
+	} else if values, err := stringArgs.Unpack(data); err == nil && len(values) == 1 {
+		str, _ = values[0].(string)
+	}
+	return sanitizeTokenString(str)
+}
+
+// drop invalid utf-8 and control characters some contracts return
+func sanitizeTokenString(str string) string {
+	if !utf8.ValidString(str) {
+		str = strings.ToValidUTF8(str, "")
+	}
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, str))
+}
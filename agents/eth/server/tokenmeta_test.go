@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+)
+
+func TestDecodeTokenString(t *testing.T) {
+	abiString, err := stringArgs.Pack("USD Coin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// MKR returns symbol as bytes32
+	bytes32 := common.RightPadBytes([]byte("MKR"), 32)
+
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"abi string", abiString, "USD Coin"},
+		{"bytes32", bytes32, "MKR"},
+		{"empty", nil, ""},
+		{"garbage", []byte{1, 2, 3}, ""},
+		{"control chars", common.RightPadBytes([]byte("A\x01B\xff"), 32), "AB"},
+	}
+	for _, c := range cases {
+		if got := decodeTokenString(c.data); got != c.want {
+			t.Errorf("%s: expected '%s', got '%s'", c.name, c.want, got)
+		}
+	}
+}
+
+func TestDecodeTokenDecimals(t *testing.T) {
+	if d, ok := decodeTokenDecimals(common.LeftPadBytes([]byte{18}, 32)); !ok || d != 18 {
+		t.Fatalf("expected 18, got %d %v", d, ok)
+	}
+	if _, ok := decodeTokenDecimals(nil); ok {
+		t.Fatal("empty result must not be decoded as decimals")
+	}
+	if _, ok := decodeTokenDecimals(common.LeftPadBytes(big.NewInt(256).Bytes(), 32)); ok {
+		t.Fatal("decimals above uint8 must be rejected")
+	}
+}
+
+func TestGetTokenMetadataNotToken(t *testing.T) {
+	// contract without token methods returns empty data
+	srv := newTestServer(t, map[string]any{"eth_call": "0x"})
+
+	if _, err := srv.GetTokenMetadata(context.Background(), "0x0000000000000000000000000000000000000100"); !errors.Is(err, rpcerrors.ErrInvalidCurrency) {
+		t.Errorf("expected invalid currency, got %v", err)
+	}
+	if _, err := srv.GetTokenMetadata(context.Background(), "0x0000000000000000000000000000000000000100:1"); !errors.Is(err, rpcerrors.ErrInvalidCurrency) {
+		t.Errorf("expected invalid currency for nft, got %v", err)
+	}
+}
@@ -1,30 +1,58 @@
 package server
 
 import (
+	"encoding/binary"
+	"fmt"
 	"log/slog"
 	"math/big"
 	"strings"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ubtr/ubt-go/agents/eth/contracts/erc1155"
 	ethtypes "github.com/ubtr/ubt-go/agents/eth/types"
 	"github.com/ubtr/ubt/go/api/proto"
 )
 
 const Erc20Transfer = "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef" //Transfer(address,address,uint256)
 
+var Erc1155TransferSingle = common.HexToHash("c3d58168c5ae7397731d063d5bbf3d657854427343f4c083240f7aacaa2d0f62") //TransferSingle(address,address,address,uint256,uint256)
+var Erc1155TransferBatch = common.HexToHash("4a39dc06d4c0dbc64b70af90fd698a233a518aa5d07e595d983b8c0526c8f7fb")  //TransferBatch(address,address,address,uint256[],uint256[])
+
+var erc1155Abi = sync.OnceValues(erc1155.Erc1155MetaData.GetAbi)
+
+// proto.Transfer statuses
+const (
+	TransferStatusUnknown uint32 = 0 // no receipt available
+	TransferStatusSuccess uint32 = 1
+	TransferStatusFailed  uint32 = 2 // transaction reverted, transfer did not move balances
+)
+
+func transferStatus(receipt *ethtypes.RpcReceipt) uint32 {
+	if receipt == nil {
+		return TransferStatusUnknown
+	}
+	if receipt.Succeeded() {
+		return TransferStatusSuccess
+	}
+	return TransferStatusFailed
+}
+
 type TxConverter struct {
-	Srv *EthServer
+	Srv     *EthServer
+	Trace   *ethtypes.CallFrame // call trace of the transaction, if internal transfers are enabled
+	BaseFee *big.Int            // base fee of the block, used to derive fee if receipt has no effective gas price
 
 	Log *slog.Logger
 }
 
-func (c *TxConverter) Convert(ethTx *ethtypes.RpcTx, logs []types.Log) (*proto.Transaction, error) {
+func (c *TxConverter) Convert(ethTx *ethtypes.RpcTx, logs []types.Log, receipt *ethtypes.RpcReceipt) (*proto.Transaction, error) {
 	transfers := []*proto.Transfer{}
 
 	c.Log.Debug("TxLogs", "logs", logs)
 	if ethTx.Tx.Value().Sign() > 0 {
-		transfer, err := c.ConvertNativeTransfer(ethTx)
+		transfer, err := c.ConvertNativeTransfer(ethTx, receipt)
 		if err != nil {
 			return nil, err
 		}
@@ -33,12 +61,37 @@ func (c *TxConverter) Convert(ethTx *ethtypes.RpcTx, logs []types.Log) (*proto.T
 		}
 	}
 
+	if c.Trace != nil {
+		internalTransfers := c.ConvertInternalTransfers(ethTx, c.Trace)
+		transfers = append(transfers, internalTransfers...)
+	}
+
 	if len(logs) > 0 {
-		erc20Transfers, err := c.ConvertERC20Transfer(ethTx, logs)
+		erc20Transfers, err := c.ConvertERC20Transfer(ethTx, logs, receipt)
 		if err != nil {
 			return nil, err
 		}
 		transfers = append(transfers, erc20Transfers...)
+
+		transfers = append(transfers, c.ConvertERC721Transfers(ethTx, logs, receipt)...)
+
+		erc1155Transfers, err := c.ConvertERC1155Transfers(ethTx, logs, receipt)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, erc1155Transfers...)
+	}
+
+	if receipt == nil {
+		return nil, fmt.Errorf("no receipt for tx %s", ethTx.TxHash)
+	}
+	fee, err := receipt.Fee(ethTx.Tx, c.BaseFee)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee of tx %s: %w", ethTx.TxHash, err)
+	}
+	feeBytes := []byte{0}
+	if fee.Sign() > 0 {
+		feeBytes = fee.Bytes()
 	}
 
 	valueBytes := []byte{0}
@@ -52,14 +105,14 @@ func (c *TxConverter) Convert(ethTx *ethtypes.RpcTx, logs []types.Log) (*proto.T
 		To:        c.Srv.AddressToString(ethTx.Tx.To()),
 		BlockId:   ethTx.BlockHash.Bytes(),
 		Type:      uint32(ethTx.Tx.Type()),
-		Fee:       &proto.Uint256{Data: []byte{0}},
+		Fee:       &proto.Uint256{Data: feeBytes},
 		Amount:    &proto.Uint256{Data: valueBytes},
 		Idx:       uint32(ethTx.TxExtraInfo.TransactionIndex),
 		Transfers: transfers,
 	}, nil
 }
 
-func (c *TxConverter) ConvertNativeTransfer(ethTx *ethtypes.RpcTx) (*proto.Transfer, error) {
+func (c *TxConverter) ConvertNativeTransfer(ethTx *ethtypes.RpcTx, receipt *ethtypes.RpcReceipt) (*proto.Transfer, error) {
 	trfId := append(ethTx.TxHash.Bytes(), 0)
 	return &proto.Transfer{
 		Id:     trfId,
@@ -67,16 +120,53 @@ func (c *TxConverter) ConvertNativeTransfer(ethTx *ethtypes.RpcTx) (*proto.Trans
 		OpId:   trfId,
 		From:   c.Srv.AddressToString(ethTx.TxExtraInfo.From),
 		To:     c.Srv.AddressToString(ethTx.Tx.To()),
-		Status: 1,
+		Status: transferStatus(receipt),
 		Amount: &proto.CurrencyAmount{CurrencyId: "", Value: &proto.Uint256{Data: ethTx.Tx.Value().Bytes()}},
 	}, nil
 }
 
-func (c *TxConverter) ConvertERC20Transfer(ethTx *ethtypes.RpcTx, logs []types.Log) ([]*proto.Transfer, error) {
+// native transfers made by contract calls; top level call is skipped as it is reported by ConvertNativeTransfer
+func (c *TxConverter) ConvertInternalTransfers(ethTx *ethtypes.RpcTx, trace *ethtypes.CallFrame) []*proto.Transfer {
+	var transfers []*proto.Transfer
+	var idx uint32
+	var walk func(frame *ethtypes.CallFrame, failed bool)
+	walk = func(frame *ethtypes.CallFrame, failed bool) {
+		// reverted frame reverts all nested calls
+		failed = failed || frame.Error != ""
+		for i := range frame.Calls {
+			call := &frame.Calls[i]
+			callFailed := failed || call.Error != ""
+			// delegate and static calls do not move value
+			if call.Value != nil && call.Value.ToInt().Sign() > 0 && call.Type != "DELEGATECALL" && call.Type != "STATICCALL" {
+				trfId := binary.BigEndian.AppendUint32(append(ethTx.TxHash.Bytes(), 0xff), idx)
+				idx++
+				status := TransferStatusSuccess
+				if callFailed {
+					status = TransferStatusFailed
+				}
+				transfers = append(transfers, &proto.Transfer{
+					Id:     trfId,
+					TxId:   ethTx.TxHash.Bytes(),
+					OpId:   trfId,
+					From:   c.Srv.AddressToString(&call.From),
+					To:     c.Srv.AddressToString(call.To),
+					Status: status,
+					Amount: &proto.CurrencyAmount{CurrencyId: "", Value: &proto.Uint256{Data: call.Value.ToInt().Bytes()}},
+				})
+			}
+			walk(call, callFailed)
+		}
+	}
+	walk(trace, false)
+	return transfers
+}
+
+func (c *TxConverter) ConvertERC20Transfer(ethTx *ethtypes.RpcTx, logs []types.Log, receipt *ethtypes.RpcReceipt) ([]*proto.Transfer, error) {
 	var transfers []*proto.Transfer
 	for _, log := range logs {
-		if len(log.Topics) > 2 && strings.HasSuffix(log.Topics[0].Hex(), Erc20Transfer) {
-			transfer, err := c.DecodeLogAsTransfer(ethTx, log)
+		// erc-721 uses the same event with indexed token id, so exactly three topics
+		if len(log.Topics) == 3 && strings.HasSuffix(log.Topics[0].Hex(), Erc20Transfer) {
+			transfer, err := c.DecodeLogAsTransfer(ethTx, log, receipt)
 			if err != nil {
 				return nil, err
 			}
@@ -87,7 +177,7 @@ func (c *TxConverter) ConvertERC20Transfer(ethTx *ethtypes.RpcTx, logs []types.L
 	return transfers, nil
 }
 
-func (c *TxConverter) DecodeLogAsTransfer(ethTx *ethtypes.RpcTx, log types.Log) (*proto.Transfer, error) {
+func (c *TxConverter) DecodeLogAsTransfer(ethTx *ethtypes.RpcTx, log types.Log, receipt *ethtypes.RpcReceipt) (*proto.Transfer, error) {
 	currencyId := c.Srv.AddressToString(&log.Address)
 	fromAddr := common.BytesToAddress(log.Topics[1].Bytes())
 	toAddr := common.BytesToAddress(log.Topics[2].Bytes())
@@ -98,7 +188,85 @@ func (c *TxConverter) DecodeLogAsTransfer(ethTx *ethtypes.RpcTx, log types.Log)
 		OpId:   trfId,
 		From:   c.Srv.AddressToString(&fromAddr),
 		To:     c.Srv.AddressToString(&toAddr),
-		Status: 0,
+		Status: transferStatus(receipt),
 		Amount: &proto.CurrencyAmount{CurrencyId: currencyId, Value: &proto.Uint256{Data: log.Data}},
 	}, nil
 }
+
+// nft transfers from erc-721 Transfer logs: token id is the fourth topic, data is empty
+func (c *TxConverter) ConvertERC721Transfers(ethTx *ethtypes.RpcTx, logs []types.Log, receipt *ethtypes.RpcReceipt) []*proto.Transfer {
+	var transfers []*proto.Transfer
+	for _, log := range logs {
+		if len(log.Topics) != 4 || !strings.HasSuffix(log.Topics[0].Hex(), Erc20Transfer) {
+			continue
+		}
+		fromAddr := common.BytesToAddress(log.Topics[1].Bytes())
+		toAddr := common.BytesToAddress(log.Topics[2].Bytes())
+		tokenId := log.Topics[3].Big()
+		trfId := binary.BigEndian.AppendUint32(append(ethTx.TxHash.Bytes(), 0xfd), uint32(log.Index))
+		transfers = append(transfers, &proto.Transfer{
+			Id:     trfId,
+			TxId:   ethTx.TxHash.Bytes(),
+			OpId:   trfId,
+			From:   c.Srv.AddressToString(&fromAddr),
+			To:     c.Srv.AddressToString(&toAddr),
+			Status: transferStatus(receipt),
+			Amount: &proto.CurrencyAmount{CurrencyId: c.Srv.AddressToString(&log.Address) + ":" + tokenId.String(), Value: &proto.Uint256{Data: []byte{1}}},
+		})
+	}
+	return transfers
+}
+
+// per token transfers from TransferSingle and TransferBatch logs
+func (c *TxConverter) ConvertERC1155Transfers(ethTx *ethtypes.RpcTx, logs []types.Log, receipt *ethtypes.RpcReceipt) ([]*proto.Transfer, error) {
+	var transfers []*proto.Transfer
+	for _, log := range logs {
+		if len(log.Topics) != 4 || (log.Topics[0] != Erc1155TransferSingle && log.Topics[0] != Erc1155TransferBatch) {
+			continue
+		}
+		parsed, err := erc1155Abi()
+		if err != nil {
+			return nil, err
+		}
+		var ids, values []*big.Int
+		if log.Topics[0] == Erc1155TransferSingle {
+			unpacked, err := parsed.Unpack("TransferSingle", log.Data)
+			if err != nil || len(unpacked) != 2 {
+				c.Log.Warn("Malformed TransferSingle log", "logIndex", log.Index, "err", err)
+				continue
+			}
+			ids = []*big.Int{unpacked[0].(*big.Int)}
+			values = []*big.Int{unpacked[1].(*big.Int)}
+		} else {
+			unpacked, err := parsed.Unpack("TransferBatch", log.Data)
+			if err != nil || len(unpacked) != 2 {
+				c.Log.Warn("Malformed TransferBatch log", "logIndex", log.Index, "err", err)
+				continue
+			}
+			ids = unpacked[0].([]*big.Int)
+			values = unpacked[1].([]*big.Int)
+			if len(ids) != len(values) {
+				c.Log.Warn("TransferBatch ids and values length mismatch", "logIndex", log.Index)
+				continue
+			}
+		}
+
+		fromAddr := common.BytesToAddress(log.Topics[2].Bytes())
+		toAddr := common.BytesToAddress(log.Topics[3].Bytes())
+		contract := c.Srv.AddressToString(&log.Address)
+		for i := range ids {
+			trfId := binary.BigEndian.AppendUint32(append(ethTx.TxHash.Bytes(), 0xfe), uint32(log.Index))
+			trfId = binary.BigEndian.AppendUint32(trfId, uint32(i))
+			transfers = append(transfers, &proto.Transfer{
+				Id:     trfId,
+				TxId:   ethTx.TxHash.Bytes(),
+				OpId:   trfId,
+				From:   c.Srv.AddressToString(&fromAddr),
+				To:     c.Srv.AddressToString(&toAddr),
+				Status: transferStatus(receipt),
+				Amount: &proto.CurrencyAmount{CurrencyId: contract + ":" + ids[i].String(), Value: &proto.Uint256{Data: values[i].Bytes()}},
+			})
+		}
+	}
+	return transfers, nil
+}
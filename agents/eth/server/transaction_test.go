@@ -0,0 +1,164 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethtypes "github.com/ubtr/ubt-go/agents/eth/types"
+)
+
+const testCallTrace = `{
+	"type": "CALL", "from": "0x0000000000000000000000000000000000000001", "to": "0x0000000000000000000000000000000000000002", "value": "0x1",
+	"calls": [
+		{"type": "CALL", "from": "0x0000000000000000000000000000000000000002", "to": "0x0000000000000000000000000000000000000003", "value": "0x10"},
+		{"type": "DELEGATECALL", "from": "0x0000000000000000000000000000000000000002", "to": "0x0000000000000000000000000000000000000004", "value": "0x1",
+			"calls": [
+				{"type": "CALL", "from": "0x0000000000000000000000000000000000000002", "to": "0x0000000000000000000000000000000000000005", "value": "0x20"}
+			]
+		},
+		{"type": "CALL", "from": "0x0000000000000000000000000000000000000002", "to": "0x0000000000000000000000000000000000000006", "value": "0x0", "error": "execution reverted",
+			"calls": [
+				{"type": "CALL", "from": "0x0000000000000000000000000000000000000006", "to": "0x0000000000000000000000000000000000000007", "value": "0x30"}
+			]
+		}
+	]
+}`
+
+func TestConvertInternalTransfers(t *testing.T) {
+	var trace ethtypes.CallFrame
+	if err := json.Unmarshal([]byte(testCallTrace), &trace); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &TxConverter{Srv: &EthServer{}, Log: slog.Default()}
+	transfers := c.ConvertInternalTransfers(&ethtypes.RpcTx{}, &trace)
+
+	if len(transfers) != 3 {
+		t.Fatalf("expected 3 internal transfers, got %d", len(transfers))
+	}
+	expected := []struct {
+		to     string
+		status uint32
+	}{
+		{"0x0000000000000000000000000000000000000003", TransferStatusSuccess},
+		{"0x0000000000000000000000000000000000000005", TransferStatusSuccess},
+		{"0x0000000000000000000000000000000000000007", TransferStatusFailed},
+	}
+	for i, e := range expected {
+		if transfers[i].To != e.to || transfers[i].Status != e.status {
+			t.Errorf("transfer %d: expected to %s status %d, got to %s status %d", i, e.to, e.status, transfers[i].To, transfers[i].Status)
+		}
+	}
+	if string(transfers[0].Id) == string(transfers[1].Id) {
+		t.Errorf("expected unique transfer ids")
+	}
+}
+
+func TestConvertERC1155Transfers(t *testing.T) {
+	parsed, err := erc1155Abi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := parsed.Events["TransferBatch"].Inputs.NonIndexed().Pack([]*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(10), big.NewInt(20)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract := common.HexToAddress("0x0000000000000000000000000000000000000100")
+	log := types.Log{
+		Address: contract,
+		Topics: []common.Hash{
+			Erc1155TransferBatch,
+			common.HexToHash("0x01"),
+			common.HexToHash("0x02"),
+			common.HexToHash("0x03"),
+		},
+		Data: data,
+	}
+
+	c := &TxConverter{Srv: &EthServer{}, Log: slog.Default()}
+	transfers, err := c.ConvertERC1155Transfers(&ethtypes.RpcTx{}, []types.Log{log}, &ethtypes.RpcReceipt{Status: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 2 {
+		t.Fatalf("expected 2 transfers, got %d", len(transfers))
+	}
+	for i, e := range []struct {
+		currency string
+		value    int64
+	}{
+		{"0x0000000000000000000000000000000000000100:1", 10},
+		{"0x0000000000000000000000000000000000000100:2", 20},
+	} {
+		tr := transfers[i]
+		if tr.Amount.CurrencyId != e.currency || new(big.Int).SetBytes(tr.Amount.Value.Data).Int64() != e.value {
+			t.Errorf("transfer %d: expected %s %d, got %s %x", i, e.currency, e.value, tr.Amount.CurrencyId, tr.Amount.Value.Data)
+		}
+		if tr.From != "0x0000000000000000000000000000000000000002" || tr.To != "0x0000000000000000000000000000000000000003" {
+			t.Errorf("transfer %d: unexpected from %s to %s", i, tr.From, tr.To)
+		}
+		if tr.Status != TransferStatusSuccess {
+			t.Errorf("transfer %d: expected success status, got %d", i, tr.Status)
+		}
+	}
+	if string(transfers[0].Id) == string(transfers[1].Id) {
+		t.Errorf("expected unique transfer ids")
+	}
+}
+
+func TestConvertERC721Transfers(t *testing.T) {
+	transferTopic := common.HexToHash(Erc20Transfer)
+	contract := common.HexToAddress("0x0000000000000000000000000000000000000100")
+	logs := []types.Log{
+		{Address: contract, Topics: []common.Hash{transferTopic, common.HexToHash("0x02"), common.HexToHash("0x03"), common.HexToHash("0x2a")}},
+		{Address: contract, Topics: []common.Hash{transferTopic, common.HexToHash("0x02"), common.HexToHash("0x03")}, Data: common.LeftPadBytes([]byte{5}, 32)},
+	}
+
+	c := &TxConverter{Srv: &EthServer{}, Log: slog.Default()}
+	erc20Transfers, err := c.ConvertERC20Transfer(&ethtypes.RpcTx{}, logs, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(erc20Transfers) != 1 {
+		t.Fatalf("expected 1 erc20 transfer, got %d", len(erc20Transfers))
+	}
+
+	nftTransfers := c.ConvertERC721Transfers(&ethtypes.RpcTx{}, logs, nil)
+	if len(nftTransfers) != 1 {
+		t.Fatalf("expected 1 nft transfer, got %d", len(nftTransfers))
+	}
+	if nftTransfers[0].Amount.CurrencyId != "0x0000000000000000000000000000000000000100:42" {
+		t.Errorf("unexpected nft currency id %s", nftTransfers[0].Amount.CurrencyId)
+	}
+	if new(big.Int).SetBytes(nftTransfers[0].Amount.Value.Data).Int64() != 1 {
+		t.Errorf("expected nft amount 1, got %x", nftTransfers[0].Amount.Value.Data)
+	}
+}
+
+func TestConvertFee(t *testing.T) {
+	to := common.HexToAddress("0x02")
+	blockHash := common.HexToHash("0xaa")
+	tx := &ethtypes.RpcTx{Tx: types.NewTx(&types.DynamicFeeTx{To: &to, GasTipCap: big.NewInt(2), GasFeeCap: big.NewInt(20)}), TxExtraInfo: ethtypes.TxExtraInfo{BlockHash: &blockHash}}
+	c := &TxConverter{Srv: &EthServer{}, BaseFee: big.NewInt(10), Log: slog.Default()}
+
+	// node without effectiveGasPrice in receipts
+	txProto, err := c.Convert(tx, nil, &ethtypes.RpcReceipt{Status: 1, GasUsed: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee := new(big.Int).SetBytes(txProto.Fee.Data); fee.Int64() != 1200 {
+		t.Errorf("expected fee 1200, got %s", fee)
+	}
+
+	if _, err := c.Convert(tx, nil, nil); err == nil {
+		t.Error("expected error for tx without receipt")
+	}
+	// dynamic fee tx price can not be derived without base fee
+	if _, err := (&ethtypes.RpcReceipt{GasUsed: 100}).Fee(tx.Tx, nil); err == nil {
+		t.Error("expected error without base fee")
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/agents/eth/rpc"
+	ethtypes "github.com/ubtr/ubt-go/agents/eth/types"
+	"github.com/ubtr/ubt-go/commons/jsonrpc"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ agent.TxStatusProvider = (*EthServer)(nil)
+
+func (srv *EthServer) GetTransactionStatus(ctx context.Context, req *agent.TransactionStatusRequest) (*agent.TransactionStatus, error) {
+	if len(req.Id) != common.HashLength {
+		return nil, rpcerrors.ArgError("id", fmt.Errorf("invalid tx id length %d", len(req.Id)))
+	}
+	txHash := common.BytesToHash(req.Id)
+
+	var batch jsonrpc.RpcBatch
+	txCall := rpc.GetTransactionByHash(txHash)
+	txCall.AddToBatch(&batch)
+	receiptCall := rpc.GetTransactionReceipt(txHash)
+	receiptCall.AddToBatch(&batch)
+	headCall := rpc.GetBlockNumber()
+	headCall.AddToBatch(&batch)
+
+	if err := batch.Call(ctx, srv.C); err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get tx status")
+	}
+	for _, err := range []error{txCall.ProcessRes(ctx), receiptCall.ProcessRes(ctx), headCall.ProcessRes(ctx)} {
+		if err != nil {
+			return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get tx status")
+		}
+	}
+
+	ret := &agent.TransactionStatus{Id: req.Id}
+	receipt := *receiptCall.Response
+	if receipt != nil {
+		ret.Status = agent.TxStatusIncluded
+		if !receipt.Succeeded() {
+			ret.Status = agent.TxStatusFailed
+		}
+		ret.BlockId = receipt.BlockHash.Bytes()
+		ret.BlockNumber = uint64(receipt.BlockNumber)
+		head := *headCall.Response
+		if head >= ret.BlockNumber {
+			ret.Confirmations = head - ret.BlockNumber + 1
+		}
+		ret.GasUsed = uint64(receipt.GasUsed)
+		ret.EffectiveFee = srv.receiptFee(ctx, receipt, *txCall.Response)
+		if ret.Status == agent.TxStatusFailed && *txCall.Response != nil {
+			reason, err := srv.ReplayRevertReason(ctx, *txCall.Response, ret.BlockNumber)
+			if err != nil {
+				srv.Log.Debug("failed to replay reverted tx", "txId", txHash, "err", err)
+			}
+			ret.RevertReason = reason
+		}
+		return ret, nil
+	}
+
+	if *txCall.Response != nil {
+		ret.Status = agent.TxStatusPending
+		return ret, nil
+	}
+
+	// unknown to the node, check if nonce was used by other transaction
+	if req.From == "" || len(req.RawData) == 0 {
+		ret.Status = agent.TxStatusDropped
+		return ret, nil
+	}
+	from, err := srv.AddressFromString(req.From)
+	if err != nil {
+		return nil, err
+	}
+	tx := &types.Transaction{}
+	if err := tx.UnmarshalBinary(req.RawData); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to unmarshal raw tx: %v", err)
+	}
+	accountNonce, err := rpc.AdoptClient(srv.C).NonceAt(ctx, from, nil)
+	if err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get nonce")
+	}
+	if accountNonce > tx.Nonce() {
+		ret.Status = agent.TxStatusReplaced
+	} else {
+		ret.Status = agent.TxStatusDropped
+	}
+	return ret, nil
+}
+
+// fee of included tx, block base fee is fetched only if receipt has no effective gas price; nil if unknown
+func (srv *EthServer) receiptFee(ctx context.Context, receipt *ethtypes.RpcReceipt, tx *ethtypes.RpcTx) *big.Int {
+	if tx == nil {
+		fee, _ := receipt.Fee(nil, nil)
+		return fee
+	}
+	var baseFee *big.Int
+	if receipt.EffectiveGasPrice == nil && tx.Tx.Type() >= types.DynamicFeeTxType {
+		block, err := rpc.GetBlockByHash(receipt.BlockHash, false).Call(ctx, srv.C)
+		if err != nil || block == nil {
+			srv.Log.Debug("failed to get block base fee", "blockId", receipt.BlockHash, "err", err)
+			return nil
+		}
+		baseFee = block.Header.BaseFee
+	}
+	fee, err := receipt.Fee(tx.Tx, baseFee)
+	if err != nil {
+		srv.Log.Debug("failed to get tx fee", "txId", receipt.TxHash, "err", err)
+	}
+	return fee
+}
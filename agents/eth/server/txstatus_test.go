@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ubtr/ubt-go/agent"
+)
+
+func TestGetTransactionStatus(t *testing.T) {
+	from := "0x0000000000000000000000000000000000000001"
+	to := common.HexToAddress("0x02")
+	tx := types.NewTx(&types.LegacyTx{Nonce: 5, To: &to, Gas: 21000, GasPrice: big.NewInt(2), Value: big.NewInt(0)})
+	rawTx, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockHash := common.HexToHash("0x0b")
+	rpcTx := map[string]any{
+		"hash": tx.Hash().Hex(), "type": "0x0", "nonce": "0x5", "gas": "0x5208", "gasPrice": "0x2", "value": "0x0", "input": "0x",
+		"from": from, "to": to.Hex(), "v": "0x0", "r": "0x0", "s": "0x0",
+	}
+	receipt := func(status string) map[string]any {
+		return map[string]any{
+			"transactionHash": tx.Hash().Hex(), "transactionIndex": "0x0", "blockHash": blockHash.Hex(), "blockNumber": "0x10",
+			"status": status, "gasUsed": "0x5208", "effectiveGasPrice": "0x2", "logs": []any{},
+		}
+	}
+
+	for _, c := range []struct {
+		name     string
+		results  map[string]any
+		req      agent.TransactionStatusRequest
+		expected agent.TransactionStatus
+	}{
+		{
+			name:     "pending",
+			results:  map[string]any{"eth_getTransactionByHash": rpcTx, "eth_getTransactionReceipt": nil, "eth_blockNumber": "0x12"},
+			expected: agent.TransactionStatus{Status: agent.TxStatusPending},
+		},
+		{
+			name:     "included",
+			results:  map[string]any{"eth_getTransactionByHash": rpcTx, "eth_getTransactionReceipt": receipt("0x1"), "eth_blockNumber": "0x12"},
+			expected: agent.TransactionStatus{Status: agent.TxStatusIncluded, BlockId: blockHash.Bytes(), BlockNumber: 16, Confirmations: 3, GasUsed: 21000, EffectiveFee: big.NewInt(42000)},
+		},
+		{
+			name: "failed",
+			results: map[string]any{"eth_getTransactionByHash": rpcTx, "eth_getTransactionReceipt": receipt("0x0"), "eth_blockNumber": "0x10",
+				"eth_call": &testRpcError{code: 3, msg: "execution reverted: paused"}},
+			expected: agent.TransactionStatus{Status: agent.TxStatusFailed, BlockId: blockHash.Bytes(), BlockNumber: 16, Confirmations: 1, GasUsed: 21000, EffectiveFee: big.NewInt(42000), RevertReason: "paused"},
+		},
+		{
+			name:     "unknown hash",
+			results:  map[string]any{"eth_getTransactionByHash": nil, "eth_getTransactionReceipt": nil, "eth_blockNumber": "0x12"},
+			expected: agent.TransactionStatus{Status: agent.TxStatusDropped},
+		},
+		{
+			name:     "dropped with unused nonce",
+			results:  map[string]any{"eth_getTransactionByHash": nil, "eth_getTransactionReceipt": nil, "eth_blockNumber": "0x12", "eth_getTransactionCount": "0x5"},
+			req:      agent.TransactionStatusRequest{From: from, RawData: rawTx},
+			expected: agent.TransactionStatus{Status: agent.TxStatusDropped},
+		},
+		{
+			name:     "nonce used by other tx",
+			results:  map[string]any{"eth_getTransactionByHash": nil, "eth_getTransactionReceipt": nil, "eth_blockNumber": "0x12", "eth_getTransactionCount": "0x6"},
+			req:      agent.TransactionStatusRequest{From: from, RawData: rawTx},
+			expected: agent.TransactionStatus{Status: agent.TxStatusReplaced},
+		},
+	} {
+		srv := newTestServer(t, c.results)
+		c.req.Id = tx.Hash().Bytes()
+		res, err := srv.GetTransactionStatus(context.Background(), &c.req)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if res.Status != c.expected.Status {
+			t.Errorf("%s: expected status %s, got %s", c.name, c.expected.Status, res.Status)
+		}
+		if common.BytesToHash(res.BlockId) != common.BytesToHash(c.expected.BlockId) || res.BlockNumber != c.expected.BlockNumber ||
+			res.Confirmations != c.expected.Confirmations || res.GasUsed != c.expected.GasUsed {
+			t.Errorf("%s: unexpected inclusion %+v", c.name, res)
+		}
+		if (res.EffectiveFee == nil) != (c.expected.EffectiveFee == nil) ||
+			(res.EffectiveFee != nil && res.EffectiveFee.Cmp(c.expected.EffectiveFee) != 0) {
+			t.Errorf("%s: expected fee %s, got %s", c.name, c.expected.EffectiveFee, res.EffectiveFee)
+		}
+		if res.RevertReason != c.expected.RevertReason {
+			t.Errorf("%s: expected revert reason '%s', got '%s'", c.name, c.expected.RevertReason, res.RevertReason)
+		}
+	}
+}
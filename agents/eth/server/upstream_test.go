@@ -0,0 +1,180 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ubtr/ubt-go/commons/jsonrpc/client"
+)
+
+type testRpcRequest struct {
+	Id     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// result computed from request params
+type testRpcHandler func(params []json.RawMessage) any
+
+// json-rpc upstream answering methods with canned results, testRpcHandler or *testRpcError;
+// unknown methods get method not found error
+func newTestUpstream(t *testing.T, results map[string]any) *client.BalancedClient {
+	handler := func(req testRpcRequest) map[string]any {
+		res := map[string]any{"jsonrpc": "2.0", "id": req.Id}
+		result, ok := results[req.Method]
+		if h, isHandler := result.(testRpcHandler); isHandler {
+			result = h(req.Params)
+		}
+		if rpcErr, isErr := result.(*testRpcError); isErr {
+			res["error"] = map[string]any{"code": rpcErr.code, "message": rpcErr.msg}
+		} else if ok {
+			res["result"] = result
+		} else {
+			res["error"] = map[string]any{"code": -32601, "message": "the method " + req.Method + " does not exist"}
+		}
+		return res
+	}
+	httpSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if len(body) > 0 && body[0] == '[' {
+			var reqs []testRpcRequest
+			json.Unmarshal(body, &reqs)
+			var res []map[string]any
+			for _, req := range reqs {
+				res = append(res, handler(req))
+			}
+			json.NewEncoder(w).Encode(res)
+			return
+		}
+		var req testRpcRequest
+		json.Unmarshal(body, &req)
+		json.NewEncoder(w).Encode(handler(req))
+	}))
+	t.Cleanup(httpSrv.Close)
+
+	// metric labels must be unique per upstream
+	c := client.NewBalancedClient([]*client.ClientConfig{{Url: httpSrv.URL, LimitRps: 1000, Labels: []any{"upstream", httpSrv.URL}}}, nil).Start()
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func newTestServer(t *testing.T, results map[string]any) *EthServer {
+	return &EthServer{C: newTestUpstream(t, results), ChainId: big.NewInt(1), Log: slog.Default()}
+}
+
+// stub chain of empty blocks served by eth_blockNumber, eth_getBlockByNumber and eth_getBlockByHash;
+// side chain blocks are known by hash only
+type testChain struct {
+	t         *testing.T
+	mu        sync.Mutex
+	canonical []common.Hash
+	blocks    map[common.Hash]map[string]any
+}
+
+func newTestChain(t *testing.T, length int) *testChain {
+	c := &testChain{t: t, blocks: map[common.Hash]map[string]any{}}
+	for i := 0; i < length; i++ {
+		c.Extend()
+	}
+	return c
+}
+
+func testBlockHash(number uint64, fork byte) common.Hash {
+	return common.BigToHash(new(big.Int).SetUint64(number<<8 | uint64(fork)))
+}
+
+// add block on top of parent, canonical blocks replace the ones at the same height and above
+func (c *testChain) AddBlock(number uint64, parent common.Hash, hash common.Hash, canonical bool) {
+	header := &types.Header{Number: new(big.Int).SetUint64(number), ParentHash: parent, Difficulty: big.NewInt(0)}
+	raw, err := json.Marshal(header)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	var block map[string]any
+	if err := json.Unmarshal(raw, &block); err != nil {
+		c.t.Fatal(err)
+	}
+	block["hash"] = hash.Hex()
+	block["transactions"] = []any{}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.blocks[hash] = block
+	if canonical {
+		c.canonical = append(c.canonical[:number], hash)
+	}
+}
+
+// add canonical block on top of the chain
+func (c *testChain) Extend() common.Hash {
+	c.mu.Lock()
+	number := uint64(len(c.canonical))
+	var parent common.Hash
+	if number > 0 {
+		parent = c.canonical[number-1]
+	}
+	c.mu.Unlock()
+	hash := testBlockHash(number, 0)
+	c.AddBlock(number, parent, hash, true)
+	return hash
+}
+
+func (c *testChain) Hash(number uint64) common.Hash {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.canonical[number]
+}
+
+func (c *testChain) Results() map[string]any {
+	return map[string]any{
+		"eth_blockNumber": testRpcHandler(func(params []json.RawMessage) any {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return hexutil.Uint64(len(c.canonical) - 1)
+		}),
+		"eth_getBlockByNumber": testRpcHandler(func(params []json.RawMessage) any {
+			var tag string
+			if err := json.Unmarshal(params[0], &tag); err != nil {
+				return &testRpcError{code: -32602, msg: err.Error()}
+			}
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			number, err := hexutil.DecodeUint64(tag)
+			if tag == "latest" {
+				number, err = uint64(len(c.canonical)-1), nil
+			}
+			if err != nil {
+				return &testRpcError{code: -32602, msg: "unknown block tag " + tag}
+			}
+			if number >= uint64(len(c.canonical)) {
+				return nil
+			}
+			return c.blocks[c.canonical[number]]
+		}),
+		"eth_getBlockByHash": testRpcHandler(func(params []json.RawMessage) any {
+			var hash common.Hash
+			if err := json.Unmarshal(params[0], &hash); err != nil {
+				return &testRpcError{code: -32602, msg: err.Error()}
+			}
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if block, ok := c.blocks[hash]; ok {
+				return block
+			}
+			return nil
+		}),
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/params"
+	ethtypes "github.com/ubtr/ubt-go/agents/eth/types"
+	"github.com/ubtr/ubt/go/api/proto"
+)
+
+// kinds of synthetic per-block operations, appended to block hash to form their ids
+const (
+	BlockOpWithdrawals byte = 0x01
+)
+
+// type of synthetic transactions carrying block-level operations, outside of eth tx types range
+const TxTypeBlockOperation uint32 = 0x100
+
+// id of synthetic operation of given kind within block
+func BlockOperationId(block *ethtypes.HeaderWithBody, kind byte) []byte {
+	return append(block.BlockHash.Bytes(), kind)
+}
+
+// beacon chain withdrawals as native inbound transfers within one synthetic operation;
+// transfer ids are built from consensus layer withdrawal index so they are stable for the block
+func (c *BlockConverter) ConvertWithdrawals(block *ethtypes.HeaderWithBody, idx uint32) *proto.Transaction {
+	if len(block.Body.Withdrawals) == 0 {
+		return nil
+	}
+	opId := BlockOperationId(block, BlockOpWithdrawals)
+	var transfers []*proto.Transfer
+	for _, w := range block.Body.Withdrawals {
+		amount := new(big.Int).Mul(new(big.Int).SetUint64(w.Amount), big.NewInt(params.GWei))
+		address := w.Address
+		transfers = append(transfers, &proto.Transfer{
+			Id:     binary.BigEndian.AppendUint64(append([]byte{}, opId...), w.Index),
+			TxId:   opId,
+			OpId:   opId,
+			To:     c.Srv.AddressToString(&address),
+			Status: TransferStatusSuccess,
+			Amount: &proto.CurrencyAmount{CurrencyId: "", Value: &proto.Uint256{Data: amount.Bytes()}},
+		})
+	}
+	return &proto.Transaction{
+		Id:         opId,
+		BlockId:    block.BlockHash.Bytes(),
+		Idx:        idx,
+		Type:       TxTypeBlockOperation,
+		Fee:        &proto.Uint256{Data: []byte{0}},
+		Amount:     &proto.Uint256{Data: []byte{0}},
+		Transfers:  transfers,
+		Operations: []*proto.Operation{{Id: opId, Type: uint32(BlockOpWithdrawals)}},
+	}
+}
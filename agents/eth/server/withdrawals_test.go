@@ -0,0 +1,52 @@
+package server
+
+import (
+	"bytes"
+	"log/slog"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	ethtypes "github.com/ubtr/ubt-go/agents/eth/types"
+)
+
+func TestConvertWithdrawals(t *testing.T) {
+	block := &ethtypes.HeaderWithBody{
+		BlockHash: common.HexToHash("0xaa"),
+		Body: ethtypes.RpcBody{Withdrawals: []*types.Withdrawal{
+			{Index: 7, Validator: 1, Address: common.HexToAddress("0x01"), Amount: 2},
+			{Index: 8, Validator: 2, Address: common.HexToAddress("0x02"), Amount: 3},
+		}},
+	}
+	c := &BlockConverter{Srv: &EthServer{}, Log: slog.Default()}
+
+	if c.ConvertWithdrawals(&ethtypes.HeaderWithBody{}, 0) != nil {
+		t.Error("expected no operation for block without withdrawals")
+	}
+
+	tx := c.ConvertWithdrawals(block, 5)
+	if tx == nil || len(tx.Transfers) != 2 || tx.Idx != 5 {
+		t.Fatalf("unexpected withdrawals operation %v", tx)
+	}
+	opId := BlockOperationId(block, BlockOpWithdrawals)
+	for i, tr := range tx.Transfers {
+		if !bytes.Equal(tr.OpId, opId) || !bytes.Equal(tr.TxId, tx.Id) {
+			t.Errorf("transfer %d: expected to be attached to block operation", i)
+		}
+	}
+	if tx.Transfers[0].To != "0x0000000000000000000000000000000000000001" {
+		t.Errorf("unexpected receiver %s", tx.Transfers[0].To)
+	}
+	if new(big.Int).SetBytes(tx.Transfers[1].Amount.Value.Data).Cmp(big.NewInt(3_000_000_000)) != 0 {
+		t.Errorf("expected amount in wei, got %x", tx.Transfers[1].Amount.Value.Data)
+	}
+	if bytes.Equal(tx.Transfers[0].Id, tx.Transfers[1].Id) {
+		t.Error("expected unique transfer ids")
+	}
+	// ids do not depend on withdrawals order
+	again := c.ConvertWithdrawals(&ethtypes.HeaderWithBody{BlockHash: block.BlockHash, Body: ethtypes.RpcBody{Withdrawals: block.Body.Withdrawals[1:]}}, 0)
+	if !bytes.Equal(again.Transfers[0].Id, tx.Transfers[1].Id) {
+		t.Error("expected stable transfer ids")
+	}
+}
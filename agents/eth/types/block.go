@@ -2,6 +2,8 @@ package ethtypes
 
 import (
 	"encoding/json"
+	"errors"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -41,6 +43,7 @@ func (tx *RpcTx) UnmarshalJSON(input []byte) error {
 
 type RpcBody struct {
 	Transactions []*RpcTx
+	Withdrawals  []*types.Withdrawal // beacon chain withdrawals, since Shanghai
 }
 
 type HeaderWithBody struct {
@@ -68,13 +71,26 @@ func (b *HeaderWithBody) UnmarshalJSON(input []byte) error {
 	}
 
 	var txStruct struct {
-		Transactions []*RpcTx `json:"transactions"`
+		Transactions []json.RawMessage   `json:"transactions"`
+		Withdrawals  []*types.Withdrawal `json:"withdrawals"`
 	}
 
 	if err := json.Unmarshal(fixedInput, &txStruct); err != nil {
 		return err
 	}
-	b.Body.Transactions = txStruct.Transactions
+	b.Body.Withdrawals = txStruct.Withdrawals
+	b.Body.Transactions = nil
+	for _, rawTx := range txStruct.Transactions {
+		// block requested without tx details has only tx hashes
+		if len(rawTx) > 0 && rawTx[0] == '"' {
+			continue
+		}
+		var tx RpcTx
+		if err := json.Unmarshal(rawTx, &tx); err != nil {
+			return err
+		}
+		b.Body.Transactions = append(b.Body.Transactions, &tx)
+	}
 
 	return nil
 }
@@ -85,3 +101,76 @@ type TxExtraInfo struct {
 	From             *common.Address `json:"from,omitempty"`
 	TransactionIndex hexutil.Uint64  `json:"transactionIndex"`
 }
+
+// transaction receipt; lenient version of types.Receipt as some eth-like nodes omit fields required there
+type RpcReceipt struct {
+	TxHash            common.Hash     `json:"transactionHash"`
+	TransactionIndex  hexutil.Uint64  `json:"transactionIndex"`
+	BlockHash         common.Hash     `json:"blockHash"`
+	BlockNumber       hexutil.Uint64  `json:"blockNumber"`
+	From              *common.Address `json:"from,omitempty"`
+	To                *common.Address `json:"to,omitempty"`
+	Status            hexutil.Uint64  `json:"status"`
+	GasUsed           hexutil.Uint64  `json:"gasUsed"`
+	EffectiveGasPrice *hexutil.Big    `json:"effectiveGasPrice,omitempty"`
+	BlobGasUsed       hexutil.Uint64  `json:"blobGasUsed,omitempty"`
+	BlobGasPrice      *hexutil.Big    `json:"blobGasPrice,omitempty"`
+	ContractAddress   *common.Address `json:"contractAddress,omitempty"`
+	Logs              []types.Log     `json:"logs"`
+}
+
+func (r *RpcReceipt) Succeeded() bool {
+	return uint64(r.Status) == types.ReceiptStatusSuccessful
+}
+
+// gas price paid by the transaction; older and some L2 nodes omit effectiveGasPrice, then it is derived from
+// the transaction: gas price of legacy txs, base fee plus effective tip of dynamic fee txs
+func (r *RpcReceipt) GasPrice(tx *types.Transaction, baseFee *big.Int) (*big.Int, error) {
+	if r.EffectiveGasPrice != nil {
+		return r.EffectiveGasPrice.ToInt(), nil
+	}
+	if tx == nil {
+		return nil, errors.New("no effective gas price in receipt")
+	}
+	if baseFee == nil {
+		if tx.Type() >= types.DynamicFeeTxType {
+			return nil, errors.New("no effective gas price in receipt and no base fee")
+		}
+		return tx.GasPrice(), nil
+	}
+	tip, err := tx.EffectiveGasTip(baseFee)
+	if err != nil {
+		return nil, err
+	}
+	return tip.Add(tip, baseFee), nil
+}
+
+// fee actually paid by the transaction: gasUsed * gas price plus blob fee if any
+func (r *RpcReceipt) Fee(tx *types.Transaction, baseFee *big.Int) (*big.Int, error) {
+	gasPrice, err := r.GasPrice(tx, baseFee)
+	if err != nil {
+		return nil, err
+	}
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(uint64(r.GasUsed)), gasPrice)
+	if r.BlobGasPrice != nil && r.BlobGasUsed > 0 {
+		fee.Add(fee, new(big.Int).Mul(new(big.Int).SetUint64(uint64(r.BlobGasUsed)), r.BlobGasPrice.ToInt()))
+	}
+	return fee, nil
+}
+
+// call frame produced by callTracer
+type CallFrame struct {
+	Type  string          `json:"type"`
+	From  common.Address  `json:"from"`
+	To    *common.Address `json:"to,omitempty"`
+	Value *hexutil.Big    `json:"value,omitempty"`
+	Error string          `json:"error,omitempty"`
+	Calls []CallFrame     `json:"calls,omitempty"`
+}
+
+// trace of a single transaction in debug_traceBlockBy* result
+type TxTrace struct {
+	TxHash *common.Hash `json:"txHash,omitempty"`
+	Result *CallFrame   `json:"result"`
+	Error  string       `json:"error,omitempty"`
+}
@@ -0,0 +1,64 @@
+package trx
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/agents/eth/rpc"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"github.com/ubtr/ubt/go/api/proto"
+	"github.com/ubtr/ubt/go/api/proto/services"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+var _ agent.AccountInfoProvider = (*TrxAgent)(nil)
+
+func (srv *TrxAgent) GetAccount(ctx context.Context, req *services.GetAccountRequest) (*proto.Account, error) {
+	if req.ChainId != nil && req.ChainId.Type != srv.Chain.Type {
+		return nil, rpcerrors.ErrInvalidChainId
+	}
+	info, err := srv.GetAccountInfo(ctx, req.Address)
+	if err != nil {
+		return nil, err
+	}
+	if err := grpc.SetHeader(ctx, info.Metadata()); err != nil {
+		srv.Log.Debug("failed to set account header", "err", err)
+	}
+	return &proto.Account{
+		Id:   req.Address,
+		Type: info.ProtoType(),
+	}, nil
+}
+
+// tron has no account nonce; accounts have to be activated by receiving trx before they can send transactions
+func (srv *TrxAgent) GetAccountInfo(ctx context.Context, address string) (*agent.AccountInfo, error) {
+	if srv.client == nil {
+		return nil, errors.ErrUnsupported
+	}
+	account, err := srv.AddressFromString(address)
+	if err != nil {
+		return nil, err
+	}
+	code, err := rpc.GetCode(account, nil).Call(ctx, srv.C)
+	if err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get account code")
+	}
+	trxAccount, err := srv.client.GetAccount(ctx, srv.AddressToString(&account))
+	if err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get account")
+	}
+
+	info := &agent.AccountInfo{Address: address, Kind: agent.AccountEOA}
+	if len(code) > 0 || trxAccount.Type == "Contract" {
+		info.Kind = agent.AccountContract
+	}
+	if len(code) > 0 {
+		info.CodeHash = crypto.Keccak256(code)
+	}
+	activated := trxAccount.Address != ""
+	info.Activated = &activated
+	return info, nil
+}
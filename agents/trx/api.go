@@ -87,7 +87,7 @@ type RawTxData struct {
 
 type TriggerContractRequest struct {
 	OwnerAddress    string `json:"owner_address"`
-	ContractAddress string `json:"contract_address"`
+	ContractAddress string `json:"contract_address,omitempty"` // empty to estimate contract deployment
 	FeeLimit        uint64 `json:"fee_limit"`
 	CallValue       uint64 `json:"call_value"`
 	Data            string `json:"data"`
@@ -113,14 +113,36 @@ type TriggerConstantContractResponse struct {
 		Code    string `json:"code"`
 		Message string `json:"message"`
 	} `json:"result"`
-	EnergyUsed  uint64 `json:"energy_used"`
-	Transaction struct {
+	EnergyUsed     uint64   `json:"energy_used"`
+	ConstantResult []string `json:"constant_result"` // hex encoded return or revert data
+	Transaction    struct {
 		TxId       string    `json:"txID"`
 		RawData    RawTxData `json:"raw_data"`
 		RawDataHex string    `json:"raw_data_hex"`
+		Ret        []struct {
+			Ret string `json:"ret"`
+		} `json:"ret"`
 	} `json:"transaction"`
 }
 
+type DeployContractRequest struct {
+	OwnerAddress               string `json:"owner_address"`
+	Abi                        string `json:"abi"`
+	Bytecode                   string `json:"bytecode"`
+	FeeLimit                   uint64 `json:"fee_limit"`
+	CallValue                  uint64 `json:"call_value"`
+	Name                       string `json:"name"`
+	ConsumeUserResourcePercent uint64 `json:"consume_user_resource_percent"`
+	OriginEnergyLimit          uint64 `json:"origin_energy_limit"`
+	Visible                    bool   `json:"visible"`
+}
+
+func (c *TrxApiClient) DeployContract(ctx context.Context, req DeployContractRequest) (CreateTransactionResponse, error) {
+	var res CreateTransactionResponse
+	err := c.DoPost(ctx, "/wallet/deploycontract", req, &res)
+	return res, err
+}
+
 func (c *TrxApiClient) TriggerConstantContract(ctx context.Context, req TriggerContractRequest) (TriggerConstantContractResponse, error) {
 	var res TriggerConstantContractResponse
 	err := c.DoPost(ctx, "/wallet/triggerconstantcontract", req, &res)
@@ -180,3 +202,82 @@ func (c *TrxApiClient) GetChainParameters(ctx context.Context) (ChainParameters,
 	}
 	return res, err
 }
+
+type TransactionIdRequest struct {
+	Value   string `json:"value"`
+	Visible bool   `json:"visible"`
+}
+
+type TransactionInfo struct {
+	Id             string `json:"id"`
+	Fee            int64  `json:"fee"`
+	BlockNumber    uint64 `json:"blockNumber"`
+	BlockTimeStamp int64  `json:"blockTimeStamp"`
+	Result         string `json:"result"` // FAILED for failed transactions, empty otherwise
+	ResMessage     string `json:"resMessage"`
+	Receipt        struct {
+		Result           string `json:"result"`
+		EnergyUsageTotal uint64 `json:"energy_usage_total"`
+		NetUsage         uint64 `json:"net_usage"`
+	} `json:"receipt"`
+}
+
+// info of included transaction, empty Id if transaction is not included yet
+func (c *TrxApiClient) GetTransactionInfoById(ctx context.Context, txId string) (TransactionInfo, error) {
+	var res TransactionInfo
+	err := c.DoPost(ctx, "/wallet/gettransactioninfobyid", TransactionIdRequest{Value: txId, Visible: true}, &res)
+	return res, err
+}
+
+type PendingTransaction struct {
+	TxId string `json:"txID"`
+}
+
+// transaction from pending pool, empty TxId if transaction is not pending
+func (c *TrxApiClient) GetTransactionFromPending(ctx context.Context, txId string) (PendingTransaction, error) {
+	var res PendingTransaction
+	err := c.DoPost(ctx, "/wallet/gettransactionfrompending", TransactionIdRequest{Value: txId, Visible: true}, &res)
+	return res, err
+}
+
+type BlockHeaderInfo struct {
+	BlockId     string `json:"blockID"`
+	BlockHeader struct {
+		RawData struct {
+			Number    uint64 `json:"number"`
+			Timestamp int64  `json:"timestamp"`
+		} `json:"raw_data"`
+	} `json:"block_header"`
+}
+
+func (c *TrxApiClient) GetNowBlock(ctx context.Context) (BlockHeaderInfo, error) {
+	var res BlockHeaderInfo
+	err := c.DoPost(ctx, "/wallet/getnowblock", nil, &res)
+	return res, err
+}
+
+// latest solidified (irreversible) block
+func (c *TrxApiClient) GetSolidifiedNowBlock(ctx context.Context) (BlockHeaderInfo, error) {
+	var res BlockHeaderInfo
+	err := c.DoPost(ctx, "/walletsolidity/getnowblock", nil, &res)
+	return res, err
+}
+
+type AccountRequest struct {
+	Address string `json:"address"`
+	Visible bool   `json:"visible"`
+}
+
+// account as returned by /wallet/getaccount; empty for addresses which were never activated
+type Account struct {
+	Address    string `json:"address"`
+	Balance    int64  `json:"balance"`
+	CreateTime int64  `json:"create_time"`
+	Type       string `json:"type"`
+}
+
+func (c *TrxApiClient) GetAccount(ctx context.Context, address string) (Account, error) {
+	var res Account
+	err := c.DoPost(ctx, "/wallet/getaccount", AccountRequest{Address: address, Visible: true}, &res)
+	return res, err
+}
@@ -0,0 +1,199 @@
+package trx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/blockchain/trx"
+	"github.com/ubtr/ubt-go/commons/conv/uint256conv"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"github.com/ubtr/ubt/go/api/proto/services"
+	"google.golang.org/grpc/codes"
+)
+
+// fee limit for deployment if node is not able to estimate it, 1000 TRX
+const DEPLOY_FEE_LIMIT = 1_000_000_000
+
+// energy of the deployer spent per call of the contract if not set in request, same as tronweb default
+const DEFAULT_ORIGIN_ENERGY_LIMIT = 10_000_000
+
+var _ agent.ContractIntentProvider = (*TrxAgent)(nil)
+
+func (srv *TrxAgent) CreateContractCall(ctx context.Context, req *agent.ContractCallRequest) (*services.TransactionIntent, error) {
+	if srv.client == nil {
+		return nil, errors.ErrUnsupported
+	}
+	feeOpts, err := agent.FeeOptionsFromContext(ctx, &srv.Config)
+	if err != nil {
+		return nil, err
+	}
+	callValue, err := sunAmount(req.Value)
+	if err != nil {
+		return nil, err
+	}
+	data, err := srv.ContractCallData(req)
+	if err != nil {
+		return nil, err
+	}
+	return srv.triggerIntent(ctx, req.From, req.To, callValue, data, req.GasLimit, feeOpts)
+}
+
+func (srv *TrxAgent) CreateContractDeploy(ctx context.Context, req *agent.ContractDeployRequest) (*services.TransactionIntent, error) {
+	if srv.client == nil {
+		return nil, errors.ErrUnsupported
+	}
+	if len(req.Bytecode) == 0 {
+		return nil, rpcerrors.ArgError("bytecode", errors.New("empty bytecode"))
+	}
+	feeOpts, err := agent.FeeOptionsFromContext(ctx, &srv.Config)
+	if err != nil {
+		return nil, err
+	}
+	callValue, err := sunAmount(req.Value)
+	if err != nil {
+		return nil, err
+	}
+	bytecode := common.Bytes2Hex(append(append([]byte{}, req.Bytecode...), req.ConstructorArgs...))
+
+	originEnergyLimit := req.OriginEnergyLimit
+	if originEnergyLimit == 0 {
+		originEnergyLimit = DEFAULT_ORIGIN_ENERGY_LIMIT
+	}
+
+	var energy uint64
+	feeLimit := req.GasLimit
+	if feeLimit == 0 {
+		// nodes without deployment estimation fail here, fee limit falls back to a constant cap
+		estimateRes, err := srv.client.TriggerConstantContract(ctx, TriggerContractRequest{
+			OwnerAddress: req.From,
+			CallValue:    callValue,
+			Data:         bytecode,
+			Visible:      true,
+		})
+		if err == nil && estimateRes.Result.Result {
+			energy = estimateRes.EnergyUsed
+			energyFee, err := srv.estimateFee(ctx, 0, energy)
+			if err != nil {
+				return nil, err
+			}
+			if feeLimit, err = srv.feeLimit(energyFee, feeOpts); err != nil {
+				return nil, err
+			}
+		} else {
+			srv.Log.Warn("Failed to estimate deployment energy", "err", err, "code", estimateRes.Result.Code, "message", estimateRes.Result.Message)
+			feeLimit = DEPLOY_FEE_LIMIT
+		}
+	}
+
+	res, err := srv.client.DeployContract(ctx, DeployContractRequest{
+		OwnerAddress:               req.From,
+		Abi:                        "[]",
+		Bytecode:                   bytecode,
+		FeeLimit:                   feeLimit,
+		CallValue:                  callValue,
+		Name:                       req.Name,
+		ConsumeUserResourcePercent: 100,
+		OriginEnergyLimit:          originEnergyLimit,
+		Visible:                    true,
+	})
+	if err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to create deploy tx")
+	}
+	if res.Error != "" {
+		return nil, rpcerrors.UpstreamError(errors.New(res.Error), codes.InvalidArgument, "failed to create deploy tx")
+	}
+
+	bandwidthEstimate := srv.estimateBandwidth(uint64(len(res.RawDataHex)), 0)
+	feeEstimate, err := srv.estimateFee(ctx, bandwidthEstimate, energy)
+	if err != nil {
+		return nil, err
+	}
+	srv.Log.Debug("DeployIntent", "bandwidth", bandwidthEstimate, "energy", energy, "feeLimit", feeLimit)
+
+	return &services.TransactionIntent{
+		Id:            common.Hex2Bytes(res.TxId),
+		SignatureType: trx.Instance.SignatureType,
+		PayloadToSign: common.Hex2Bytes(res.TxId),
+		RawData:       res.RawData,
+		EstimatedFee:  uint256conv.FromBigInt(feeEstimate),
+	}, nil
+}
+
+// estimate energy of the call and build TriggerSmartContract intent; fee limit is derived from estimation unless overridden
+func (srv *TrxAgent) triggerIntent(ctx context.Context, from string, contract string, callValue uint64, data []byte, feeLimitOverride uint64, feeOpts agent.FeeOptions) (*services.TransactionIntent, error) {
+	estimateRes, err := srv.client.TriggerConstantContract(ctx, TriggerContractRequest{
+		OwnerAddress:    from,
+		ContractAddress: contract,
+		FeeLimit:        ERC20_FEE_LIMIT,
+		CallValue:       callValue,
+		Data:            common.Bytes2Hex(data),
+		Visible:         true,
+	})
+
+	if err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to estimate energy")
+	}
+
+	if !estimateRes.Result.Result {
+		return nil, rpcerrors.UpstreamError(fmt.Errorf("%s %s", estimateRes.Result.Code, estimateRes.Result.Message), codes.InvalidArgument, "failed to create tx")
+	}
+
+	bandwidthEstimate := srv.estimateBandwidth(uint64(len(estimateRes.Transaction.RawDataHex)), 6)
+	feeEstimate, err := srv.estimateFee(ctx, bandwidthEstimate, estimateRes.EnergyUsed)
+	if err != nil {
+		return nil, err
+	}
+
+	feeLimit := feeLimitOverride
+	if feeLimit == 0 {
+		energyFee, err := srv.estimateFee(ctx, 0, estimateRes.EnergyUsed)
+		if err != nil {
+			return nil, err
+		}
+		if feeLimit, err = srv.feeLimit(energyFee, feeOpts); err != nil {
+			return nil, err
+		}
+	}
+
+	srv.Log.Debug("TriggerIntent", "bandwidth", bandwidthEstimate, "energy", estimateRes.EnergyUsed, "speed", feeOpts.Speed, "feeLimit", feeLimit)
+
+	triggerRes, err := srv.client.TriggerSmartContract(ctx, TriggerContractRequest{
+		OwnerAddress:    from,
+		ContractAddress: contract,
+		FeeLimit:        feeLimit,
+		CallValue:       callValue,
+		Data:            common.Bytes2Hex(data),
+		Visible:         true,
+	})
+
+	if err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to create tx")
+	}
+
+	if !triggerRes.Result.Result {
+		return nil, rpcerrors.UpstreamError(fmt.Errorf("%s %s", triggerRes.Result.Code, triggerRes.Result.Message), codes.InvalidArgument, "failed to create tx")
+	}
+
+	return &services.TransactionIntent{
+		Id:            common.Hex2Bytes(triggerRes.Transaction.TxId),
+		SignatureType: trx.Instance.SignatureType,
+		PayloadToSign: common.Hex2Bytes(triggerRes.Transaction.TxId),
+		RawData:       triggerRes.Transaction.RawData,
+		EstimatedFee:  uint256conv.FromBigInt(feeEstimate),
+	}, nil
+}
+
+// native amount in suns as expected by http api
+func sunAmount(value *big.Int) (uint64, error) {
+	if value == nil {
+		return 0, nil
+	}
+	if value.Sign() < 0 || !value.IsUint64() {
+		return 0, rpcerrors.ErrInvalidAmount
+	}
+	return value.Uint64(), nil
+}
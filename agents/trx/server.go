@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/shengdoushi/base58"
 	"github.com/ubtr/ubt-go/agent"
 	"github.com/ubtr/ubt-go/agents/eth/contracts/erc20"
@@ -17,14 +18,28 @@ import (
 	"github.com/ubtr/ubt-go/commons/cache"
 	"github.com/ubtr/ubt-go/commons/conv/uint256conv"
 	"github.com/ubtr/ubt-go/commons/rpcerrors"
-	"github.com/ubtr/ubt/go/api/proto"
 	"github.com/ubtr/ubt/go/api/proto/services"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
 )
 
 const ERC20_FEE_LIMIT = 20000000
-const BlockTimeSec = 3
+
+// broadcast result code of transaction already known to the node
+const DupTransactionCode = "DUP_TRANSACTION_ERROR"
+
+// confirmation depth used when solidified block is not available
+const (
+	SafeDepth     = 19
+	FinalityDepth = 27
+)
+
+// fee limit multipliers in percents over estimated energy fee for slow, normal and fast tiers;
+// tron has no fee market so tier only sets how much energy price may grow before tx runs out of energy
+var feeLimitSpeedMultipliers = map[agent.FeeSpeed]int64{
+	agent.FeeSpeedSlow:   110,
+	agent.FeeSpeedNormal: 150,
+	agent.FeeSpeedFast:   200,
+}
 
 func init() {
 	agent.AgentFactories[trx.CODE_STR] = func(ctx context.Context, config *agent.ChainConfig) agent.UbtAgent {
@@ -47,31 +62,57 @@ var TrxExtensions = server.Extensions{
 		addressTron = append(addressTron, address.Bytes()...)
 		return trx.Address(addressTron).String()
 	},
-	BlockFinalityStatus: func(block *proto.Block) proto.FinalityStatus {
-		// https://tronprotocol.github.io/documentation-en/introduction/dpos/#:~:text=Block%20time%3A%20TRON%20sets%20block,will%20take%20up%20a%20slot.
-		if uint64(block.Header.Timestamp.Seconds) < uint64(time.Now().Unix())-27*BlockTimeSec {
-			return proto.FinalityStatus_FINALITY_STATUS_FINALIZED
-		} else if uint64(block.Header.Timestamp.Seconds) < uint64(time.Now().Unix())-19*BlockTimeSec {
-			return proto.FinalityStatus_FINALITY_STATUS_SAFE
-		} else {
-			return proto.FinalityStatus_FINALITY_STATUS_UNSAFE
+	// tron burns resource fees, block producers are paid by protocol rewards not visible in blocks
+	BlockRewards: func(header *types.Header, fees server.BlockFees) []server.BlockReward {
+		return nil
+	},
+	NewHeadsFetcher: func(srv *server.EthServer) server.HeadsFetcher {
+		if !hasHttpUrl(&srv.Config) {
+			safeDepth, finalityDepth := uint64(SafeDepth), uint64(FinalityDepth)
+			if srv.Config.SafeDepth != 0 {
+				safeDepth = srv.Config.SafeDepth
+			}
+			if srv.Config.FinalityDepth != 0 {
+				finalityDepth = srv.Config.FinalityDepth
+			}
+			return server.NewTagHeadsFetcher(srv.C, safeDepth, finalityDepth, srv.Log).Fetch
 		}
+		return SolidifiedHeadsFetcher(NewTrxApiClient(srv.Config.HttpUrls[0].Url, srv.Log))
 	},
 }
 
+// heads from solidity node: block is irreversible once confirmed by 19 of 27 super representatives
+// https://tronprotocol.github.io/documentation-en/introduction/dpos/
+func SolidifiedHeadsFetcher(api *TrxApiClient) server.HeadsFetcher {
+	return func(ctx context.Context) (*server.Heads, error) {
+		latest, err := api.GetNowBlock(ctx)
+		if err != nil {
+			return nil, err
+		}
+		solidified, err := api.GetSolidifiedNowBlock(ctx)
+		if err != nil {
+			return nil, err
+		}
+		solidifiedNumber := solidified.BlockHeader.RawData.Number
+		return &server.Heads{Latest: latest.BlockHeader.RawData.Number, Safe: solidifiedNumber, Finalized: solidifiedNumber}, nil
+	}
+}
+
+func hasHttpUrl(config *agent.ChainConfig) bool {
+	return len(config.HttpUrls) > 0 && config.HttpUrls[0].Url != ""
+}
+
 func InitServer(ctx context.Context, config *agent.ChainConfig) *TrxAgent {
 	agent := &TrxAgent{
-		EthServer:      *server.InitServer(ctx, config),
+		EthServer:      server.InitServerWithExtensions(ctx, config, TrxExtensions),
 		feePricesCache: cache.NewSimpleExpirationCache[feePrices](10 * time.Second),
 	}
-	if config.HttpUrls == nil || len(config.HttpUrls) == 0 || config.HttpUrls[0].Url == "" {
+	if !hasHttpUrl(config) {
 		agent.Log.Warn("no http url provided - trx requires http api to create/sign txs")
 	} else {
 		agent.client = NewTrxApiClient(config.HttpUrls[0].Url, agent.Log)
 	}
 
-	agent.EthServer.Extensions = TrxExtensions
-
 	return agent
 }
 
@@ -81,7 +122,7 @@ type feePrices struct {
 }
 
 type TrxAgent struct {
-	server.EthServer
+	*server.EthServer
 	client         *TrxApiClient
 	feePricesCache *cache.SimpleExpirationCache[feePrices]
 }
@@ -116,13 +157,29 @@ func (srv *TrxAgent) estimateFee(ctx context.Context, bandwidth uint64, energy u
 	feePrices, err := srv.GetFeePrices(ctx)
 	if err != nil {
 
-		return nil, status.Errorf(codes.Unavailable, "failed to get fee prices: %s", err)
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get fee prices")
 	}
 	feeEstimate := big.NewInt(0).Mul(big.NewInt(int64(bandwidth)), feePrices.bandwidthPrice)
 
 	return feeEstimate.Add(feeEstimate, big.NewInt(0).Mul(big.NewInt(int64(energy)), feePrices.energyPrice)), nil
 }
 
+// get fee limit for a contract call from its estimated energy fee, explicit max fee is used as is
+func (srv *TrxAgent) feeLimit(energyFee *big.Int, opts agent.FeeOptions) (uint64, error) {
+	if opts.MaxFee != nil {
+		if !opts.MaxFee.IsUint64() {
+			return 0, rpcerrors.ArgError(agent.MetadataMaxFee, fmt.Errorf("max fee %s overflows fee limit", opts.MaxFee))
+		}
+		return opts.MaxFee.Uint64(), nil
+	}
+	limit := new(big.Int).Mul(energyFee, big.NewInt(feeLimitSpeedMultipliers[opts.Speed]))
+	limit.Div(limit, big.NewInt(100))
+	if limit.Sign() <= 0 || !limit.IsUint64() {
+		return ERC20_FEE_LIMIT, nil
+	}
+	return limit.Uint64(), nil
+}
+
 func (srv *TrxAgent) CreateTransfer(ctx context.Context, req *services.CreateTransferRequest) (*services.TransactionIntent, error) {
 	srv.Log.Debug("CreateTransfer", "req", req, "amount", big.NewInt(0).SetBytes(req.Amount.Value.Data))
 	if srv.client == nil {
@@ -134,6 +191,11 @@ func (srv *TrxAgent) CreateTransfer(ctx context.Context, req *services.CreateTra
 		return nil, err
 	}
 
+	feeOpts, err := agent.FeeOptionsFromContext(ctx, &srv.Config)
+	if err != nil {
+		return nil, err
+	}
+
 	if curId.IsNative() {
 		res, err := srv.client.CreateTransaction(ctx, CreateTransactionRequest{
 			OwnerAddress: req.From,
@@ -143,15 +205,11 @@ func (srv *TrxAgent) CreateTransfer(ctx context.Context, req *services.CreateTra
 		})
 
 		if err != nil {
-			return nil, err
+			return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to create tx")
 		}
 
 		if res.Error != "" {
-			return nil, errors.New(res.Error)
-		}
-
-		if err != nil {
-			return nil, err
+			return nil, rpcerrors.UpstreamError(errors.New(res.Error), codes.InvalidArgument, "failed to create tx")
 		}
 
 		bandwidthEstimate := srv.estimateBandwidth(uint64(len(res.RawDataHex)), 0)
@@ -187,55 +245,7 @@ func (srv *TrxAgent) CreateTransfer(ctx context.Context, req *services.CreateTra
 			return nil, err
 		}
 
-		estimateRes, err := srv.client.TriggerConstantContract(ctx, TriggerContractRequest{
-			OwnerAddress:    req.From,
-			ContractAddress: curId.Address,
-			FeeLimit:        ERC20_FEE_LIMIT,
-			CallValue:       0,
-			Data:            common.Bytes2Hex(data),
-			Visible:         true,
-		})
-
-		if err != nil {
-			return nil, err
-		}
-
-		if !estimateRes.Result.Result {
-			return nil, fmt.Errorf("failed to create tx: %s %s", estimateRes.Result.Code, estimateRes.Result.Message)
-		}
-
-		bandwidthEstimate := srv.estimateBandwidth(uint64(len(estimateRes.Transaction.RawDataHex)), 6)
-		feeEstimate, err := srv.estimateFee(ctx, bandwidthEstimate, estimateRes.EnergyUsed)
-		if err != nil {
-			return nil, err
-		}
-
-		srv.Log.Debug("TransferIntent", "bandwidth", bandwidthEstimate, "energy", estimateRes.EnergyUsed)
-
-		triggerRes, err := srv.client.TriggerSmartContract(ctx, TriggerContractRequest{
-			OwnerAddress:    req.From,
-			ContractAddress: curId.Address,
-			FeeLimit:        ERC20_FEE_LIMIT,
-			CallValue:       0,
-			Data:            common.Bytes2Hex(data),
-			Visible:         true,
-		})
-
-		if err != nil {
-			return nil, err
-		}
-
-		if !triggerRes.Result.Result {
-			return nil, fmt.Errorf("failed to create tx: %s %s", triggerRes.Result.Code, triggerRes.Result.Message)
-		}
-
-		return &services.TransactionIntent{
-			Id:            common.Hex2Bytes(triggerRes.Transaction.TxId),
-			SignatureType: trx.Instance.SignatureType,
-			PayloadToSign: common.Hex2Bytes(triggerRes.Transaction.TxId),
-			RawData:       triggerRes.Transaction.RawData,
-			EstimatedFee:  uint256conv.FromBigInt(feeEstimate),
-		}, nil
+		return srv.triggerIntent(ctx, req.From, curId.Address, 0, data, 0, feeOpts)
 
 	} else {
 		return nil, rpcerrors.ErrInvalidCurrency
@@ -248,6 +258,14 @@ func (srv *TrxAgent) Send(ctx context.Context, req *services.TransactionSendRequ
 		return nil, errors.ErrUnsupported
 	}
 
+	dryRun, err := agent.DryRunFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return srv.dryRunSend(req)
+	}
+
 	var signatures []string
 	for _, signature := range req.Signatures {
 		signatures = append(signatures, common.Bytes2Hex(signature))
@@ -259,11 +277,14 @@ func (srv *TrxAgent) Send(ctx context.Context, req *services.TransactionSendRequ
 	})
 
 	if err != nil {
-		return nil, status.Errorf(codes.Unavailable, "Failed to broadcast tx %s", err.Error())
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to broadcast tx")
 	}
 
-	if !res.Result {
-		return nil, status.Errorf(codes.InvalidArgument, "failed to broadcast tx: %s %s", res.Code, res.Message)
+	if !res.Result && res.Code == DupTransactionCode {
+		// same transaction was broadcast before
+		srv.Log.Debug("tx already known", "txId", common.Bytes2Hex(req.Intent.Id))
+	} else if !res.Result {
+		return nil, rpcerrors.UpstreamError(fmt.Errorf("%s %s", res.Code, res.Message), codes.InvalidArgument, "failed to broadcast tx")
 	}
 
 	return &services.TransactionSendResponse{
@@ -0,0 +1,143 @@
+package trx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/agents/eth/server"
+	"github.com/ubtr/ubt-go/blockchain/trx"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"github.com/ubtr/ubt/go/api/proto/services"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ agent.Simulator = (*TrxAgent)(nil)
+
+// first contract of visible (json) raw transaction data
+type rawContract struct {
+	Type      string `json:"type"`
+	Parameter struct {
+		Value struct {
+			OwnerAddress    string `json:"owner_address"`
+			ContractAddress string `json:"contract_address"`
+			Data            string `json:"data"`
+			CallValue       uint64 `json:"call_value"`
+			NewContract     struct {
+				Bytecode  string `json:"bytecode"`
+				CallValue uint64 `json:"call_value"`
+			} `json:"new_contract"`
+		} `json:"value"`
+	} `json:"parameter"`
+}
+
+type rawTx struct {
+	Contract   []rawContract `json:"contract"`
+	Expiration int64         `json:"expiration"` // unix ms
+}
+
+func parseRawTx(rawData []byte) (*rawTx, error) {
+	var tx rawTx
+	if err := json.Unmarshal(rawData, &tx); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to unmarshal raw tx: %v", err)
+	}
+	if len(tx.Contract) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "raw tx has no contract")
+	}
+	return &tx, nil
+}
+
+// replay contract call or deployment with triggerconstantcontract; plain trx transfers execute no code
+func (srv *TrxAgent) SimulateIntent(ctx context.Context, req *agent.SimulationRequest) (*agent.SimulationResult, error) {
+	if srv.client == nil {
+		return nil, errors.ErrUnsupported
+	}
+	if req.Intent == nil {
+		return nil, rpcerrors.ArgError("intent", errors.New("intent is required"))
+	}
+	tx, err := parseRawTx(req.Intent.RawData)
+	if err != nil {
+		return nil, err
+	}
+	contract := tx.Contract[0]
+	value := contract.Parameter.Value
+
+	trigger := TriggerContractRequest{OwnerAddress: value.OwnerAddress, FeeLimit: ERC20_FEE_LIMIT, Visible: true}
+	switch contract.Type {
+	case "TriggerSmartContract":
+		trigger.ContractAddress = value.ContractAddress
+		trigger.Data = value.Data
+		trigger.CallValue = value.CallValue
+	case "CreateSmartContract":
+		trigger.Data = value.NewContract.Bytecode
+		trigger.CallValue = value.NewContract.CallValue
+	default:
+		return &agent.SimulationResult{Success: true}, nil
+	}
+
+	res, err := srv.client.TriggerConstantContract(ctx, trigger)
+	if err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to simulate tx")
+	}
+	ret := &agent.SimulationResult{Success: res.Result.Result, GasUsed: res.EnergyUsed}
+	if len(res.ConstantResult) > 0 {
+		ret.ReturnData = common.FromHex(res.ConstantResult[0])
+	}
+	for _, r := range res.Transaction.Ret {
+		if r.Ret != "" && r.Ret != "SUCCESS" {
+			ret.Success = false
+		}
+	}
+	if !ret.Success {
+		ret.RevertReason = server.DecodeRevertData(ret.ReturnData)
+		if ret.RevertReason == "" {
+			ret.RevertReason = fmt.Sprintf("%s %s", res.Result.Code, res.Result.Message)
+		}
+		ret.ReturnData = nil
+	}
+	return ret, nil
+}
+
+// check signed transaction without broadcasting: signature must belong to the owner and tx must not be expired
+func (srv *TrxAgent) dryRunSend(req *services.TransactionSendRequest) (*services.TransactionSendResponse, error) {
+	tx, err := parseRawTx(req.Intent.RawData)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Signatures) == 0 {
+		return nil, rpcerrors.ArgError("signatures", errors.New("signature is required"))
+	}
+	signer, err := recoverSigner(req.Intent.PayloadToSign, req.Signatures[0])
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid signature: %v", err)
+	}
+	if owner := tx.Contract[0].Parameter.Value.OwnerAddress; signer != owner {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid signature: signed by %s, owner is %s", signer, owner)
+	}
+	if tx.Expiration > 0 && time.UnixMilli(tx.Expiration).Before(time.Now()) {
+		return nil, status.Errorf(codes.FailedPrecondition, "transaction expired at %s", time.UnixMilli(tx.Expiration).UTC().Format(time.RFC3339))
+	}
+	return &services.TransactionSendResponse{Id: req.Intent.Id}, nil
+}
+
+// base58 address of the key which signed the transaction id
+func recoverSigner(txId []byte, signature []byte) (string, error) {
+	if len(signature) != crypto.SignatureLength {
+		return "", fmt.Errorf("invalid signature length %d", len(signature))
+	}
+	sig := append([]byte{}, signature...)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(txId, sig)
+	if err != nil {
+		return "", err
+	}
+	return trx.AddressFromPublicKey(crypto.FromECDSAPub(pub)).String(), nil
+}
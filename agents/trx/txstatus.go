@@ -0,0 +1,58 @@
+package trx
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ubtr/ubt-go/agent"
+	"github.com/ubtr/ubt-go/commons/rpcerrors"
+	"google.golang.org/grpc/codes"
+)
+
+var _ agent.TxStatusProvider = (*TrxAgent)(nil)
+
+func (srv *TrxAgent) GetTransactionStatus(ctx context.Context, req *agent.TransactionStatusRequest) (*agent.TransactionStatus, error) {
+	if srv.client == nil {
+		return nil, errors.ErrUnsupported
+	}
+	txId := common.Bytes2Hex(req.Id)
+	ret := &agent.TransactionStatus{Id: req.Id}
+
+	info, err := srv.client.GetTransactionInfoById(ctx, txId)
+	if err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get tx info")
+	}
+	if info.Id != "" {
+		ret.Status = agent.TxStatusIncluded
+		if info.Result == "FAILED" || (info.Receipt.Result != "" && info.Receipt.Result != "SUCCESS") {
+			ret.Status = agent.TxStatusFailed
+		}
+		ret.BlockNumber = info.BlockNumber
+		ret.GasUsed = info.Receipt.EnergyUsageTotal
+		ret.EffectiveFee = big.NewInt(info.Fee)
+
+		head, err := srv.client.GetNowBlock(ctx)
+		if err != nil {
+			return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get head block")
+		}
+		headNumber := head.BlockHeader.RawData.Number
+		if headNumber >= info.BlockNumber {
+			ret.Confirmations = headNumber - info.BlockNumber + 1
+		}
+		return ret, nil
+	}
+
+	pending, err := srv.client.GetTransactionFromPending(ctx, txId)
+	if err != nil {
+		return nil, rpcerrors.UpstreamError(err, codes.Unavailable, "failed to get pending tx")
+	}
+	if pending.TxId != "" {
+		ret.Status = agent.TxStatusPending
+	} else {
+		// tron has no nonces so transaction can not be replaced, it only expires
+		ret.Status = agent.TxStatusDropped
+	}
+	return ret, nil
+}
@@ -0,0 +1,147 @@
+/*
+BIP32 public key derivation from extended public keys, private keys are never involved
+*/
+
+package hd
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/shengdoushi/base58"
+	"golang.org/x/crypto/ripemd160"
+)
+
+const HardenedOffset uint32 = 0x80000000
+
+// BIP44 levels: m / purpose' / coin_type' / account' / change / address_index
+const (
+	Bip44Purpose      = 44
+	Bip44AccountDepth = 3
+)
+
+const serializedKeyLen = 78
+
+var ErrHardenedDerivation = errors.New("hardened child can not be derived from public key")
+
+type ExtendedPublicKey struct {
+	Version           [4]byte
+	Depth             uint8
+	ParentFingerprint [4]byte
+	ChildNumber       uint32
+	ChainCode         [32]byte
+	Key               *secp256k1.PublicKey
+}
+
+// parse base58check encoded extended public key (xpub, tpub or other version prefixes)
+func ParseExtendedPublicKey(encoded string) (*ExtendedPublicKey, error) {
+	data, err := base58.Decode(encoded, base58.BitcoinAlphabet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extended key encoding: %w", err)
+	}
+	if len(data) != serializedKeyLen+4 {
+		return nil, fmt.Errorf("invalid extended key length %d", len(data))
+	}
+	payload, checksum := data[:serializedKeyLen], data[serializedKeyLen:]
+	if !bytes.Equal(doubleSha256(payload)[:4], checksum) {
+		return nil, errors.New("invalid extended key checksum")
+	}
+	if payload[45] == 0 {
+		return nil, errors.New("extended private keys are not accepted")
+	}
+
+	key, err := secp256k1.ParsePubKey(payload[45:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid extended key point: %w", err)
+	}
+	ret := &ExtendedPublicKey{
+		Depth:       payload[4],
+		ChildNumber: binary.BigEndian.Uint32(payload[9:13]),
+		Key:         key,
+	}
+	copy(ret.Version[:], payload[:4])
+	copy(ret.ParentFingerprint[:], payload[5:9])
+	copy(ret.ChainCode[:], payload[13:45])
+	return ret, nil
+}
+
+// derive non-hardened child key (CKDpub)
+func (k *ExtendedPublicKey) Child(index uint32) (*ExtendedPublicKey, error) {
+	if index >= HardenedOffset {
+		return nil, ErrHardenedDerivation
+	}
+	if k.Depth == 255 {
+		return nil, errors.New("maximum derivation depth reached")
+	}
+	mac := hmac.New(sha512.New, k.ChainCode[:])
+	mac.Write(k.Key.SerializeCompressed())
+	mac.Write(binary.BigEndian.AppendUint32(nil, index))
+	sum := mac.Sum(nil)
+
+	var tweak secp256k1.ModNScalar
+	if overflow := tweak.SetByteSlice(sum[:32]); overflow {
+		return nil, fmt.Errorf("invalid child %d, use next index", index)
+	}
+	var tweakPoint, parentPoint, childPoint secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(&tweak, &tweakPoint)
+	k.Key.AsJacobian(&parentPoint)
+	secp256k1.AddNonConst(&tweakPoint, &parentPoint, &childPoint)
+	if (childPoint.X.IsZero() && childPoint.Y.IsZero()) || childPoint.Z.IsZero() {
+		return nil, fmt.Errorf("invalid child %d, use next index", index)
+	}
+	childPoint.ToAffine()
+
+	ret := &ExtendedPublicKey{
+		Version:     k.Version,
+		Depth:       k.Depth + 1,
+		ChildNumber: index,
+		Key:         secp256k1.NewPublicKey(&childPoint.X, &childPoint.Y),
+	}
+	ret.ParentFingerprint = k.Fingerprint()
+	copy(ret.ChainCode[:], sum[32:])
+	return ret, nil
+}
+
+// key identifier prefix: first 4 bytes of hash160 of compressed key
+func (k *ExtendedPublicKey) Fingerprint() [4]byte {
+	sha := sha256.Sum256(k.Key.SerializeCompressed())
+	ripemd := ripemd160.New()
+	ripemd.Write(sha[:])
+	var ret [4]byte
+	copy(ret[:], ripemd.Sum(nil))
+	return ret
+}
+
+// derive along the non-hardened path
+func (k *ExtendedPublicKey) Derive(path ...uint32) (*ExtendedPublicKey, error) {
+	ret := k
+	for _, index := range path {
+		var err error
+		if ret, err = ret.Child(index); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// uncompressed 65 bytes public key as expected by address converters
+func (k *ExtendedPublicKey) PublicKey() []byte {
+	return k.Key.SerializeUncompressed()
+}
+
+// BIP44 address path under account level key of the given coin type
+func Bip44Path(coinType uint, account uint32, change uint32, index uint32) string {
+	return fmt.Sprintf("m/%d'/%d'/%d'/%d/%d", Bip44Purpose, coinType, account, change, index)
+}
+
+func doubleSha256(data []byte) []byte {
+	h0 := sha256.Sum256(data)
+	h1 := sha256.Sum256(h0[:])
+	return h1[:]
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ubtr/ubt-go/blockchain/hd"
+)
+
+// BIP32 test vector 1: m/0H and m/0H/1
+const (
+	testXpubM0H  = "xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw"
+	testXpubM0H1 = "xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ"
+)
+
+func TestExtendedPublicKeyChild(t *testing.T) {
+	parent, err := hd.ParseExtendedPublicKey(testXpubM0H)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent.Depth != 1 || parent.ChildNumber != hd.HardenedOffset {
+		t.Fatalf("unexpected parent depth %d child %x", parent.Depth, parent.ChildNumber)
+	}
+	expected, err := hd.ParseExtendedPublicKey(testXpubM0H1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	child, err := parent.Child(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(child.PublicKey(), expected.PublicKey()) || child.ChainCode != expected.ChainCode {
+		t.Fatalf("derived key %x does not match %x", child.PublicKey(), expected.PublicKey())
+	}
+	if child.Depth != expected.Depth || child.ChildNumber != expected.ChildNumber {
+		t.Fatalf("unexpected child depth %d number %d", child.Depth, child.ChildNumber)
+	}
+	if child.ParentFingerprint != expected.ParentFingerprint {
+		t.Fatalf("parent fingerprint %x does not match %x", child.ParentFingerprint, expected.ParentFingerprint)
+	}
+
+	if _, err := parent.Child(hd.HardenedOffset); !errors.Is(err, hd.ErrHardenedDerivation) {
+		t.Fatalf("expected hardened derivation error, got %v", err)
+	}
+}
+
+func TestParseExtendedPublicKeyInvalid(t *testing.T) {
+	// corrupted checksum
+	if _, err := hd.ParseExtendedPublicKey(testXpubM0H[:len(testXpubM0H)-1] + "x"); err == nil {
+		t.Fatal("expected checksum error")
+	}
+	// BIP32 test vector 1 m/0H xprv
+	if _, err := hd.ParseExtendedPublicKey("xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7"); err == nil {
+		t.Fatal("expected private key to be rejected")
+	}
+}
@@ -108,6 +108,7 @@ func main() {
 			services.RegisterUbtBlockServiceServer(s, srv)
 			services.RegisterUbtCurrencyServiceServer(s, srv)
 			services.RegisterUbtConstructServiceServer(s, srv)
+			services.RegisterUbtBalanceServiceServer(s, srv)
 
 			if cCtx.Bool("reflection") {
 				slog.Info("Enabling gRPC reflection")
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log/slog"
 	"math"
+	"slices"
 	"sync"
 	"time"
 )
@@ -222,15 +223,26 @@ func (c *ClientBalancer[T]) CallW(ctx context.Context, op func(ctx context.Conte
 	return err
 }
 
+// call op with every connected client concurrently, ignoring rate limits; returns joined errors of failed calls
 func (c *ClientBalancer[T]) CallEveryUpstream(ctx context.Context, op func(ctx context.Context, client T) error) error {
-	var err error
-	for _, client := range c.clients {
-		if !client.connected {
-			continue
-		}
-		err = op(ctx, client.client)
+	c.mu.Lock()
+	connected := slices.Clone(c.connected)
+	c.mu.Unlock()
+
+	errs := make([]error, len(connected))
+	var wg sync.WaitGroup
+	for i, client := range connected {
+		wg.Add(1)
+		go func(i int, client *clientRecord[T]) {
+			defer wg.Done()
+			errs[i] = op(ctx, client.client)
+			if client.dialer.IsConnectionError(errs[i]) {
+				c.markDisconnected(client)
+			}
+		}(i, client)
 	}
-	return err
+	wg.Wait()
+	return errors.Join(errs...)
 }
 
 func (c *ClientBalancer[T]) Close() error {
@@ -269,6 +269,46 @@ func TestMultipleGoroutineWithDisconnect(t *testing.T) {
 	assert.Len(t, vals, 6)
 }
 
+func TestCallEveryUpstreamConcurrent(t *testing.T) {
+	c1 := &testClient{Name: "client1", Connected: true}
+	c2 := &testClient{Name: "client2", Connected: false}
+	c3 := &testClient{Name: "client3", Connected: true}
+	b := NewBalancer([]ClientDialer[testc]{c1, c2, c3}).Start()
+	ctx := context.Background()
+	vals := []testc{}
+	mu := &sync.Mutex{}
+	var started sync.WaitGroup
+	started.Add(2)
+	testFunc := func(ctx context.Context, client testc) error {
+		// every call waits for the others, so sequential calls would time out
+		started.Done()
+		done := make(chan struct{})
+		go func() { started.Wait(); close(done) }()
+		select {
+		case <-done:
+		case <-time.After(1 * time.Second):
+			return errors.New("calls are not concurrent")
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		vals = append(vals, client)
+		if client == "client3" {
+			return connErr2
+		}
+		return nil
+	}
+	err := b.CallEveryUpstream(ctx, testFunc)
+
+	assert.ErrorIs(t, err, connErr2)
+	assert.True(t, array_sorted_equal(vals, []testc{testc("client1"), testc("client3")}), "all connected clients called")
+
+	err = b.CallEveryUpstream(ctx, func(ctx context.Context, client testc) error {
+		assert.Equal(t, testc("client1"), client, "disconnected client skipped")
+		return nil
+	})
+	assert.Nil(t, err)
+}
+
 func BenchmarkBalancer(b *testing.B) {
 	b.Log("R")
 	c1 := &testClient{Name: "client1", Connected: true}
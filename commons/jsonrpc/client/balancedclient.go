@@ -2,7 +2,10 @@ package client
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/rpc"
@@ -152,12 +155,41 @@ func (c *BalancedClient) Close() error {
 	return c.Balancer.Close()
 }
 
-func (c *BalancedClient) CallEveryUpstream(ctx context.Context, raw *jsonrpc.RawCall) (err error) {
-	return c.Balancer.CallEveryUpstream(ctx, func(ctx context.Context, us Upstream) error {
-		err := us.Client.CallContext(ctx, raw)
+// call every connected upstream concurrently, e.g. to propagate transaction faster; succeeds if any upstream succeeded,
+// otherwise returns errors of all upstreams joined. Result of the first successful upstream is stored in raw
+func (c *BalancedClient) CallEveryUpstream(ctx context.Context, raw *jsonrpc.RawCall) error {
+	var mu sync.Mutex
+	var errs []error
+	called, succeeded := 0, 0
+	c.Balancer.CallEveryUpstream(ctx, func(ctx context.Context, us Upstream) error {
+		// every upstream decodes into its own result
+		call := &jsonrpc.RawCall{Method: raw.Method, Params: raw.Params}
+		if raw.Result != nil {
+			call.Result = reflect.New(reflect.TypeOf(raw.Result).Elem()).Interface()
+		}
+		start := time.Now()
+		err := us.Client.CallContext(ctx, call)
+		us.Metrics.Requests.Observe(float64(time.Since(start).Seconds()))
+
+		mu.Lock()
+		defer mu.Unlock()
+		called++
 		if err != nil {
-			c.Log.Error("CallEveryUpstream", "error", err)
+			c.Log.Debug("CallEveryUpstream", "method", raw.Method, "error", err)
+			errs = append(errs, err)
+		} else {
+			if succeeded == 0 && raw.Result != nil {
+				reflect.ValueOf(raw.Result).Elem().Set(reflect.ValueOf(call.Result).Elem())
+			}
+			succeeded++
 		}
 		return err
 	})
+	if called == 0 {
+		return balancer.ErrNoUpstream
+	}
+	if succeeded > 0 {
+		return nil
+	}
+	return errors.Join(errs...)
 }
@@ -65,6 +65,11 @@ func (c *RpcCall[R]) AddToBatch(batch *RpcBatch) {
 	batch.Add(&c.raw)
 }
 
+// underlying untyped call, e.g. to send it with client specific methods; ProcessRes converts result afterwards
+func (c *RpcCall[R]) Raw() *RawCall {
+	return &c.raw
+}
+
 func (c *RpcCall[R]) ProcessRes(ctx context.Context) error {
 	if c.raw.Error != nil {
 		return c.raw.Error
@@ -1,8 +1,13 @@
 package rpcerrors
 
 import (
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -17,3 +22,58 @@ var ErrUnknown = errors.New("unknown error")
 func ArgError(argName string, err error) error {
 	return status.Errorf(codes.InvalidArgument, "invalid arg '%s': %s", argName, err.Error())
 }
+
+const ReasonReorg = "REORG"
+const ErrorDomain = "ubt"
+
+// chain reorganization detected against the block last seen by the client
+type ReorgInfo struct {
+	Orphaned       [][]byte // ids of orphaned blocks known to the node, starting from the last seen block
+	AncestorId     []byte   // id of the common ancestor, empty if it was not found
+	AncestorNumber uint64
+}
+
+func ReorgError(info ReorgInfo) error {
+	orphaned := make([]string, len(info.Orphaned))
+	for i, id := range info.Orphaned {
+		orphaned[i] = hex.EncodeToString(id)
+	}
+	st := status.New(codes.Aborted, fmt.Sprintf("chain reorganized, %d blocks orphaned", len(info.Orphaned)))
+	st, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: ReasonReorg,
+		Domain: ErrorDomain,
+		Metadata: map[string]string{
+			"orphaned":       strings.Join(orphaned, ","),
+			"ancestorId":     hex.EncodeToString(info.AncestorId),
+			"ancestorNumber": strconv.FormatUint(info.AncestorNumber, 10),
+		},
+	})
+	if err != nil {
+		return status.Error(codes.Aborted, "chain reorganized")
+	}
+	return st.Err()
+}
+
+// extract reorg details from error returned by block service
+func ReorgFromError(err error) (*ReorgInfo, bool) {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.Aborted {
+		return nil, false
+	}
+	for _, detail := range st.Details() {
+		errInfo, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || errInfo.Reason != ReasonReorg {
+			continue
+		}
+		info := &ReorgInfo{}
+		for _, idHex := range strings.Split(errInfo.Metadata["orphaned"], ",") {
+			if id, err := hex.DecodeString(idHex); err == nil && len(id) > 0 {
+				info.Orphaned = append(info.Orphaned, id)
+			}
+		}
+		info.AncestorId, _ = hex.DecodeString(errInfo.Metadata["ancestorId"])
+		info.AncestorNumber, _ = strconv.ParseUint(errInfo.Metadata["ancestorNumber"], 10, 64)
+		return info, true
+	}
+	return nil, false
+}
@@ -0,0 +1,78 @@
+package rpcerrors
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestReorgError(t *testing.T) {
+	info := ReorgInfo{Orphaned: [][]byte{{0x01, 0x02}, {0x03}}, AncestorId: []byte{0x0a}, AncestorNumber: 42}
+	parsed, ok := ReorgFromError(ReorgError(info))
+	if !ok {
+		t.Fatal("expected reorg error")
+	}
+	if len(parsed.Orphaned) != 2 || !bytes.Equal(parsed.Orphaned[0], info.Orphaned[0]) || !bytes.Equal(parsed.Orphaned[1], info.Orphaned[1]) {
+		t.Errorf("unexpected orphaned blocks %x", parsed.Orphaned)
+	}
+	if !bytes.Equal(parsed.AncestorId, info.AncestorId) || parsed.AncestorNumber != info.AncestorNumber {
+		t.Errorf("unexpected ancestor %x %d", parsed.AncestorId, parsed.AncestorNumber)
+	}
+
+	if _, ok := ReorgFromError(ErrBlockOutOfRange); ok {
+		t.Error("expected non reorg error")
+	}
+}
+
+type testRpcError struct {
+	code int
+	msg  string
+}
+
+func (e *testRpcError) Error() string  { return e.msg }
+func (e *testRpcError) ErrorCode() int { return e.code }
+
+func TestClassifyUpstreamError(t *testing.T) {
+	cases := map[string]error{
+		ReasonInsufficientFunds:      errors.New("insufficient funds for gas * price + value: balance 0"),
+		ReasonNonceTooLow:            errors.New("nonce too low: next nonce 5, tx nonce 3"),
+		ReasonReplacementUnderpriced: errors.New("replacement transaction underpriced"),
+		ReasonGasTooLow:              errors.New("intrinsic gas too low: have 20000, want 21000"),
+		ReasonExecutionReverted:      errors.New("execution reverted: paused"),
+		ReasonRateLimited:            &testRpcError{code: -32005, msg: "daily request count exceeded"},
+		ReasonAlreadyKnown:           errors.New("Known transaction: 0x01"),
+	}
+	for reason, err := range cases {
+		if got := ClassifyUpstreamError(err); got != reason {
+			t.Errorf("expected %s for '%v', got '%s'", reason, err, got)
+		}
+	}
+	if got := ClassifyUpstreamError(errors.New("execution reverted: insufficient balance")); got != ReasonExecutionReverted {
+		t.Errorf("expected %s, got '%s'", ReasonExecutionReverted, got)
+	}
+	// tron broadcast codes
+	if got := ClassifyUpstreamError(errors.New("BANDWITH_ERROR Account resource insufficient error.")); got != ReasonInsufficientFunds {
+		t.Errorf("expected %s, got '%s'", ReasonInsufficientFunds, got)
+	}
+	if got := ClassifyUpstreamError(errors.New("connection refused")); got != "" {
+		t.Errorf("expected unclassified error, got '%s'", got)
+	}
+}
+
+func TestUpstreamError(t *testing.T) {
+	err := UpstreamError(errors.New("nonce too low"), codes.Internal, "failed to send tx")
+	if status.Code(err) != codes.FailedPrecondition || ReasonFromError(err) != ReasonNonceTooLow {
+		t.Errorf("unexpected error %v, reason '%s'", err, ReasonFromError(err))
+	}
+	err = UpstreamError(errors.New("connection refused"), codes.Internal, "failed to send tx")
+	if status.Code(err) != codes.Internal || ReasonFromError(err) != "" {
+		t.Errorf("unexpected error %v, reason '%s'", err, ReasonFromError(err))
+	}
+	err = RevertError("contract call reverts", "paused")
+	if status.Code(err) != codes.FailedPrecondition || ReasonFromError(err) != ReasonExecutionReverted {
+		t.Errorf("unexpected error %v, reason '%s'", err, ReasonFromError(err))
+	}
+}
@@ -0,0 +1,117 @@
+package rpcerrors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// reasons of classified upstream errors, passed in ErrorInfo details of the status
+const (
+	ReasonInsufficientFunds      = "INSUFFICIENT_FUNDS"
+	ReasonNonceTooLow            = "NONCE_TOO_LOW"
+	ReasonReplacementUnderpriced = "REPLACEMENT_UNDERPRICED"
+	ReasonGasTooLow              = "GAS_TOO_LOW"
+	ReasonExecutionReverted      = "EXECUTION_REVERTED"
+	ReasonRateLimited            = "RATE_LIMITED"
+	ReasonAlreadyKnown           = "ALREADY_KNOWN"
+)
+
+var reasonCodes = map[string]codes.Code{
+	ReasonInsufficientFunds:      codes.FailedPrecondition,
+	ReasonNonceTooLow:            codes.FailedPrecondition,
+	ReasonReplacementUnderpriced: codes.FailedPrecondition,
+	ReasonGasTooLow:              codes.InvalidArgument,
+	ReasonExecutionReverted:      codes.FailedPrecondition,
+	ReasonRateLimited:            codes.ResourceExhausted,
+	ReasonAlreadyKnown:           codes.AlreadyExists,
+}
+
+// lowercase message fragments of eth-like nodes and tron http api, checked in order
+var reasonPatterns = []struct {
+	reason    string
+	fragments []string
+}{
+	// revert reason is contract defined text which may contain any of the fragments below
+	{ReasonExecutionReverted, []string{"execution reverted", "revert opcode executed"}},
+	// same transaction was accepted before, checked before other send errors as broadcast to every upstream joins them
+	{ReasonAlreadyKnown, []string{"already known", "known transaction", "transaction already imported", "already exists"}},
+	{ReasonRateLimited, []string{"rate limit", "too many requests", "server_busy"}},
+	{ReasonNonceTooLow, []string{"nonce too low", "nonce has already been used"}},
+	{ReasonReplacementUnderpriced, []string{"replacement transaction underpriced", "replacement fee too low"}},
+	{ReasonInsufficientFunds, []string{"insufficient funds", "insufficient balance", "balance is not sufficient", "bandwith_error", "account resource insufficient"}},
+	{ReasonGasTooLow, []string{"intrinsic gas too low", "gas too low", "gas limit too low"}},
+}
+
+// json-rpc error code of rate limited requests
+const rpcCodeLimitExceeded = -32005
+
+// classify upstream error, empty reason if it is not recognized
+func ClassifyUpstreamError(err error) string {
+	if err == nil {
+		return ""
+	}
+	var coded interface{ ErrorCode() int }
+	if errors.As(err, &coded) && coded.ErrorCode() == rpcCodeLimitExceeded {
+		return ReasonRateLimited
+	}
+	msg := strings.ToLower(err.Error())
+	for _, p := range reasonPatterns {
+		for _, fragment := range p.fragments {
+			if strings.Contains(msg, fragment) {
+				return p.reason
+			}
+		}
+	}
+	return ""
+}
+
+// status error of the given reason with ErrorInfo details; metadata is optional
+func ReasonError(reason string, msg string, metadata map[string]string) error {
+	code, ok := reasonCodes[reason]
+	if !ok {
+		code = codes.Unknown
+	}
+	st, err := status.New(code, msg).WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   ErrorDomain,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return status.Error(code, msg)
+	}
+	return st.Err()
+}
+
+// convert upstream error into status: classified errors get stable code and ErrorInfo with upstream message,
+// others are wrapped with fallback code
+func UpstreamError(err error, fallback codes.Code, msg string) error {
+	reason := ClassifyUpstreamError(err)
+	if reason == "" {
+		return status.Errorf(fallback, "%s: %v", msg, err)
+	}
+	return ReasonError(reason, fmt.Sprintf("%s: %v", msg, err), map[string]string{"upstreamMessage": err.Error()})
+}
+
+// revert of contract execution with decoded reason
+func RevertError(msg string, revertReason string) error {
+	return ReasonError(ReasonExecutionReverted, fmt.Sprintf("%s: %s", msg, revertReason), map[string]string{"revertReason": revertReason})
+}
+
+// get reason of error returned by agent, empty if error has no ErrorInfo of ubt domain
+func ReasonFromError(err error) string {
+	st, ok := status.FromError(err)
+	if !ok {
+		return ""
+	}
+	for _, detail := range st.Details() {
+		if errInfo, ok := detail.(*errdetails.ErrorInfo); ok && errInfo.Domain == ErrorDomain {
+			return errInfo.Reason
+		}
+	}
+	return ""
+}
@@ -17,6 +17,7 @@ type ServerProxy struct {
 	services.UnimplementedUbtBlockServiceServer
 	services.UnimplementedUbtConstructServiceServer
 	services.UnimplementedUbtCurrencyServiceServer
+	services.UnimplementedUbtBalanceServiceServer
 
 	servers map[string]agent.UbtAgent
 }
@@ -152,3 +153,36 @@ func (s *ServerProxy) SignTransaction(ctx context.Context, in *services.Transact
 	}
 	return nil, ErrChainNotSupported
 }
+
+func (s *ServerProxy) GetBalance(ctx context.Context, in *services.GetBalanceRequest) (*services.BalanceResponse, error) {
+	if in.ChainId == nil {
+		return nil, ErrChainIdRequired
+	}
+	chainId := commons.ChainIdToString(in.ChainId)
+	if srv, ok := s.servers[chainId]; ok {
+		return srv.GetBalance(ctx, in)
+	}
+	return nil, ErrChainNotSupported
+}
+
+func (s *ServerProxy) ListAccountBalances(ctx context.Context, in *services.ListAccountBalancesRequest) (*services.ListAccountBalancesResponse, error) {
+	if in.ChainId == nil {
+		return nil, ErrChainIdRequired
+	}
+	chainId := commons.ChainIdToString(in.ChainId)
+	if srv, ok := s.servers[chainId]; ok {
+		return srv.ListAccountBalances(ctx, in)
+	}
+	return nil, ErrChainNotSupported
+}
+
+func (s *ServerProxy) ListCurrencyHolders(ctx context.Context, in *services.ListCurrencyHoldersRequest) (*services.ListCurrencyHoldersResponse, error) {
+	if in.ChainId == nil {
+		return nil, ErrChainIdRequired
+	}
+	chainId := commons.ChainIdToString(in.ChainId)
+	if srv, ok := s.servers[chainId]; ok {
+		return srv.ListCurrencyHolders(ctx, in)
+	}
+	return nil, ErrChainNotSupported
+}